@@ -0,0 +1,270 @@
+package anomaly
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+	"github.com/deeraj-kumar/exam-audit/scoring"
+)
+
+const (
+	KindFinalBurst     = "final_burst"
+	KindKeyConvergence = "key_convergence"
+	KindAnswerVelocity = "answer_velocity"
+
+	defaultFinalWindow    = 30
+	defaultBurstShare     = 0.5
+	defaultMinBurst       = 3
+	defaultConvergeWindow = 60
+	defaultMinCorrections = 3
+	defaultVelocityZ      = 3.0
+
+	// madScale and meanADScale turn a median or mean absolute deviation into a standard deviation
+	// estimate for normal data
+	madScale    = 1.4826
+	meanADScale = 1.2533
+)
+
+// Detect looks for solo behaviour worth a closer look : a burst of answers right before the end , many
+// revisions switching to the answer key at once , and answering much faster than the rest of the cohort .
+// end is the scheduled end of the exam , when it is zero the last revision seen in the cohort stands in for it
+func Detect(exam model.Exam, studentAnswersMap map[string]map[string][]model.AnswerRevision, end int64, cfg model.AnomalyConfig) []model.StudentAnomaly {
+	cfg = withDefaults(cfg)
+
+	questions := make(map[string]model.Question, len(exam.Questions))
+	for _, q := range exam.Questions {
+		questions[q.QuestionID] = q
+	}
+
+	if end == 0 {
+		for _, answers := range studentAnswersMap {
+			for _, revisions := range answers {
+				for _, r := range revisions {
+					end = max(end, r.SubmittedAt)
+				}
+			}
+		}
+	}
+
+	studentIDs := make([]string, 0, len(studentAnswersMap))
+	for sid := range studentAnswersMap {
+		studentIDs = append(studentIDs, sid)
+	}
+	sort.Strings(studentIDs)
+
+	var out []model.StudentAnomaly
+	for _, sid := range studentIDs {
+		answers := studentAnswersMap[sid]
+		if a, ok := finalBurst(sid, answers, end, cfg); ok {
+			out = append(out, a)
+		}
+		if a, ok := keyConvergence(sid, answers, questions, cfg); ok {
+			out = append(out, a)
+		}
+	}
+	out = append(out, answerVelocity(studentIDs, studentAnswersMap, cfg)...)
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].StudentID != out[j].StudentID {
+			return out[i].StudentID < out[j].StudentID
+		}
+		return out[i].Kind < out[j].Kind
+	})
+	return out
+}
+
+func withDefaults(cfg model.AnomalyConfig) model.AnomalyConfig {
+	if cfg.FinalWindowSeconds <= 0 {
+		cfg.FinalWindowSeconds = defaultFinalWindow
+	}
+	if cfg.FinalBurstShare <= 0 {
+		cfg.FinalBurstShare = defaultBurstShare
+	}
+	if cfg.MinBurstAnswers <= 0 {
+		cfg.MinBurstAnswers = defaultMinBurst
+	}
+	if cfg.ConvergenceWindowSeconds <= 0 {
+		cfg.ConvergenceWindowSeconds = defaultConvergeWindow
+	}
+	if cfg.MinCorrections <= 0 {
+		cfg.MinCorrections = defaultMinCorrections
+	}
+	if cfg.VelocityZScore <= 0 {
+		cfg.VelocityZScore = defaultVelocityZ
+	}
+	return cfg
+}
+
+// finalBurst flags a student who settled a large share of their final answers in the closing window
+func finalBurst(sid string, answers map[string][]model.AnswerRevision, end int64, cfg model.AnomalyConfig) (model.StudentAnomaly, bool) {
+	var late []string
+	var total int
+	for qID, revisions := range answers {
+		if len(revisions) == 0 {
+			continue
+		}
+		total++
+		if left := end - revisions[len(revisions)-1].SubmittedAt; left >= 0 && left <= cfg.FinalWindowSeconds {
+			late = append(late, qID)
+		}
+	}
+	if total == 0 || len(late) < cfg.MinBurstAnswers {
+		return model.StudentAnomaly{}, false
+	}
+	share := float64(len(late)) / float64(total)
+	if share < cfg.FinalBurstShare {
+		return model.StudentAnomaly{}, false
+	}
+	sort.Strings(late)
+	return model.StudentAnomaly{
+		StudentID: sid,
+		Kind:      KindFinalBurst,
+		Score:     share,
+		Questions: late,
+		Detail:    fmt.Sprintf("%d of %d final answers entered in the last %ds", len(late), total, cfg.FinalWindowSeconds),
+	}, true
+}
+
+// keyConvergence flags a student who switched several wrong answers to the answer key within one window
+func keyConvergence(sid string, answers map[string][]model.AnswerRevision, questions map[string]model.Question, cfg model.AnomalyConfig) (model.StudentAnomaly, bool) {
+	type correction struct {
+		qID string
+		at  int64
+	}
+	var corrections []correction
+	for qID, revisions := range answers {
+		q, ok := questions[qID]
+		if !ok || q.CorrectAnswer == "" {
+			continue
+		}
+		for i := 1; i < len(revisions); i++ {
+			if !scoring.IsCorrect(q, revisions[i-1].Ans) && scoring.IsCorrect(q, revisions[i].Ans) {
+				corrections = append(corrections, correction{qID: qID, at: revisions[i].SubmittedAt})
+			}
+		}
+	}
+	if len(corrections) < cfg.MinCorrections {
+		return model.StudentAnomaly{}, false
+	}
+	sort.Slice(corrections, func(i, j int) bool {
+		if corrections[i].at != corrections[j].at {
+			return corrections[i].at < corrections[j].at
+		}
+		return corrections[i].qID < corrections[j].qID
+	})
+
+	// widest cluster of corrections inside a sliding window
+	bestStart, bestLen := 0, 0
+	for start, end := 0, 0; start < len(corrections); start++ {
+		for end < len(corrections) && corrections[end].at-corrections[start].at <= cfg.ConvergenceWindowSeconds {
+			end++
+		}
+		if end-start > bestLen {
+			bestStart, bestLen = start, end-start
+		}
+	}
+	if bestLen < cfg.MinCorrections {
+		return model.StudentAnomaly{}, false
+	}
+
+	var qIDs []string
+	for _, c := range corrections[bestStart : bestStart+bestLen] {
+		qIDs = append(qIDs, c.qID)
+	}
+	sort.Strings(qIDs)
+	return model.StudentAnomaly{
+		StudentID: sid,
+		Kind:      KindKeyConvergence,
+		Score:     float64(bestLen) / float64(len(answers)),
+		Questions: qIDs,
+		Detail:    fmt.Sprintf("%d wrong answers changed to the key within %ds", bestLen, cfg.ConvergenceWindowSeconds),
+	}, true
+}
+
+// answerVelocity flags students whose median time between first answers is far below the cohort , using
+// a robust z-score built from the cohort median and median absolute deviation
+func answerVelocity(studentIDs []string, studentAnswersMap map[string]map[string][]model.AnswerRevision, cfg model.AnomalyConfig) []model.StudentAnomaly {
+	paces := make(map[string]float64)
+	var all []float64
+	for _, sid := range studentIDs {
+		if pace, ok := medianPace(studentAnswersMap[sid]); ok {
+			paces[sid] = pace
+			all = append(all, pace)
+		}
+	}
+	if len(all) < 3 {
+		return nil
+	}
+
+	center := median(all)
+	deviations := make([]float64, 0, len(all))
+	for _, p := range all {
+		deviations = append(deviations, math.Abs(p-center))
+	}
+	spread := madScale * median(deviations)
+	if spread == 0 {
+		// more than half the cohort keeps the exact same pace , fall back to the mean absolute deviation
+		spread = meanADScale * mean(deviations)
+	}
+	if spread == 0 {
+		return nil
+	}
+
+	var out []model.StudentAnomaly
+	for _, sid := range studentIDs {
+		pace, ok := paces[sid]
+		if !ok {
+			continue
+		}
+		z := (center - pace) / spread
+		if z < cfg.VelocityZScore {
+			continue
+		}
+		out = append(out, model.StudentAnomaly{
+			StudentID: sid,
+			Kind:      KindAnswerVelocity,
+			Score:     z,
+			Detail:    fmt.Sprintf("median %.1fs between answers against a cohort median of %.1fs", pace, center),
+		})
+	}
+	return out
+}
+
+// medianPace is the median gap between the first revisions of consecutive questions
+func medianPace(answers map[string][]model.AnswerRevision) (float64, bool) {
+	var firsts []int64
+	for _, revisions := range answers {
+		if len(revisions) > 0 {
+			firsts = append(firsts, revisions[0].SubmittedAt)
+		}
+	}
+	if len(firsts) < 2 {
+		return 0, false
+	}
+	sort.Slice(firsts, func(i, j int) bool { return firsts[i] < firsts[j] })
+	gaps := make([]float64, 0, len(firsts)-1)
+	for i := 1; i < len(firsts); i++ {
+		gaps = append(gaps, float64(firsts[i]-firsts[i-1]))
+	}
+	return median(gaps), true
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,84 @@
+package anomaly_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/deeraj-kumar/exam-audit/anomaly"
+	model "github.com/deeraj-kumar/exam-audit/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	exam := model.Exam{ExamID: "e1"}
+	for i := 1; i <= 4; i++ {
+		exam.Questions = append(exam.Questions, model.Question{QuestionID: fmt.Sprintf("q%d", i), CorrectAnswer: "A"})
+	}
+
+	answers := map[string]map[string][]model.AnswerRevision{}
+	// a steady cohort answering one question every 100 seconds
+	for s := 1; s <= 6; s++ {
+		sid := fmt.Sprintf("s%d", s)
+		answers[sid] = map[string][]model.AnswerRevision{}
+		for i := 1; i <= 4; i++ {
+			answers[sid][fmt.Sprintf("q%d", i)] = []model.AnswerRevision{{SubmittedAt: int64(i*100 + s*2), Ans: "B"}}
+		}
+	}
+	// s7 rushes through everything
+	answers["s7"] = map[string][]model.AnswerRevision{
+		"q1": {{SubmittedAt: 100, Ans: "B"}},
+		"q2": {{SubmittedAt: 105, Ans: "B"}},
+		"q3": {{SubmittedAt: 110, Ans: "B"}},
+		"q4": {{SubmittedAt: 115, Ans: "B"}},
+	}
+	// s8 flips three wrong answers to the key together right before the end
+	answers["s8"] = map[string][]model.AnswerRevision{
+		"q1": {{SubmittedAt: 110, Ans: "B"}, {SubmittedAt: 990, Ans: "A"}},
+		"q2": {{SubmittedAt: 210, Ans: "C"}, {SubmittedAt: 995, Ans: "A"}},
+		"q3": {{SubmittedAt: 310, Ans: "D"}, {SubmittedAt: 1000, Ans: "A"}},
+		"q4": {{SubmittedAt: 410, Ans: "B"}},
+	}
+
+	found := map[string]model.StudentAnomaly{}
+	for _, a := range anomaly.Detect(exam, answers, 0, model.AnomalyConfig{}) {
+		found[a.StudentID+"/"+a.Kind] = a
+	}
+
+	assert.Contains(t, found, "s7/"+anomaly.KindAnswerVelocity)
+	assert.Contains(t, found, "s8/"+anomaly.KindFinalBurst)
+	assert.Contains(t, found, "s8/"+anomaly.KindKeyConvergence)
+	assert.Equal(t, []string{"q1", "q2", "q3"}, found["s8/"+anomaly.KindKeyConvergence].Questions)
+	assert.NotContains(t, found, "s1/"+anomaly.KindAnswerVelocity)
+	assert.Len(t, found, 3)
+}
+
+func TestDetect_ScheduledEnd(t *testing.T) {
+	exam := model.Exam{ExamID: "e1"}
+	answers := map[string]map[string][]model.AnswerRevision{}
+	for s := 1; s <= 3; s++ {
+		sid := fmt.Sprintf("s%d", s)
+		answers[sid] = map[string][]model.AnswerRevision{}
+		for i := 1; i <= 4; i++ {
+			answers[sid][fmt.Sprintf("q%d", i)] = []model.AnswerRevision{{SubmittedAt: int64(i*100 + s*2), Ans: "B"}}
+		}
+	}
+	// s4 answers last , long before the scheduled end
+	answers["s4"] = map[string][]model.AnswerRevision{
+		"q1": {{SubmittedAt: 990, Ans: "B"}},
+		"q2": {{SubmittedAt: 995, Ans: "B"}},
+		"q3": {{SubmittedAt: 1000, Ans: "B"}},
+	}
+
+	burst := func(end int64) bool {
+		for _, a := range anomaly.Detect(exam, answers, end, model.AnomalyConfig{}) {
+			if a.StudentID == "s4" && a.Kind == anomaly.KindFinalBurst {
+				return true
+			}
+		}
+		return false
+	}
+	// without a schedule the last revision stands in for the end
+	assert.True(t, burst(0))
+	assert.False(t, burst(1800))
+	assert.True(t, burst(1010))
+}
@@ -1,8 +1,13 @@
 package auditengine
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sync"
 
+	"github.com/deeraj-kumar/exam-audit/anomaly"
+	"github.com/deeraj-kumar/exam-audit/cluster"
 	"github.com/deeraj-kumar/exam-audit/config"
 	model "github.com/deeraj-kumar/exam-audit/domain"
 	"github.com/deeraj-kumar/exam-audit/service"
@@ -11,50 +16,356 @@ import (
 
 type ExamAuditHandler interface {
 	SubmitAnswer(studentId, examID, questionID, ans string) error
-	AuditAnswer(instructorId, examID string) (model.AuditReportResponse, error)
+	AuditAnswer(ctx context.Context, instructorId, examID string) (model.AuditReportResponse, error)
+	// LiveAudit returns the current report of the live audit of examID
+	LiveAudit(ctx context.Context, instructorId, examID string) (model.AuditReportResponse, error)
+	// StreamSuspicion sends the live report of examID and then every change to it until ctx is done
+	StreamSuspicion(ctx context.Context, instructorId, examID string) (<-chan model.SuspicionEvent, error)
+	// ReplayExam replays a finished exam at speed times its pace, zero being instant
+	ReplayExam(ctx context.Context, instructorId, examID string, speed float64, emit func(model.TimelineEvent)) error
+	// ScheduleExam creates examID as a draft accepting answers from startTime until endTime
+	ScheduleExam(instructorId, examID string, startTime, endTime int64) error
+	// SetExamState moves examID to its next state
+	SetExamState(instructorId, examID, state string) error
+	GetExamSchedule(instructorId, examID string) (model.ExamSchedule, error)
 }
 
 type examAuditHandler struct {
 	service service.FabricService
+
+	liveMu sync.Mutex
+	live   map[string]*liveExam
+}
+
+// ErrExamNotLive is returned for a live audit of a closed or audited exam
+var ErrExamNotLive = errors.New("exam is not live")
+
+// liveExam is the live audit of an open exam and the listener feeding it
+type liveExam struct {
+	ia     *util.IncrementalAudit
+	cancel context.CancelFunc
+	err    error
+	// ready closes once seeded, stopped marks an exam closed during the seed
+	ready   chan struct{}
+	stopped bool
 }
 
 func NewExamAuditHandler(svc service.FabricService) ExamAuditHandler {
-	return &examAuditHandler{service: svc}
+	return &examAuditHandler{service: svc, live: make(map[string]*liveExam)}
 }
 
 func (ea *examAuditHandler) SubmitAnswer(studentId, examID, questionID, ans string) error {
+	exams, err := util.ReadExamJSONData(config.Cfg.WorkingDir + "/data/exam_details.json")
+	if err != nil {
+		return fmt.Errorf("read exam data failed: %w", err)
+	}
+	question, err := util.FindQuestion(exams, examID, questionID)
+	if err != nil {
+		return err
+	}
+	if err := util.ValidateAnswer(question, ans); err != nil {
+		return err
+	}
+
 	if err := ea.service.SetAnswer(studentId, examID, questionID, ans); err != nil {
-		return fmt.Errorf("failed to submit answer . student id - %s , question id - %s , exam id - %s , err - %v", studentId, examID, questionID, err)
+		return fmt.Errorf("failed to submit answer . student id - %s , question id - %s , exam id - %s , err - %w", studentId, examID, questionID, err)
 	}
+	// the live audit picks the answer up from its ledger event
 	return nil
 }
 
-func (ea *examAuditHandler) AuditAnswer(instructorId, examID string) (model.AuditReportResponse, error) {
-	exams, err := util.ReadExamJSONData(config.Cfg.WorkingDir + "/data/exam_details.json")
+func (ea *examAuditHandler) AuditAnswer(ctx context.Context, instructorId, examID string) (model.AuditReportResponse, error) {
+	selectedExam, roster, answers, err := ea.loadExamHistory(examID)
 	if err != nil {
-		return model.AuditReportResponse{}, fmt.Errorf("read exam data failed: %w", err)
+		return model.AuditReportResponse{}, err
 	}
-	var selectedExam model.Exam
-	for _, e := range exams.Exams {
-		if e.ExamID == examID {
-			selectedExam = e
-			break
+	grouped := util.GenerateFlattenedTable(answers)
+
+	adj, meta, err := util.GenerateAuditReport(ctx, selectedExam, grouped)
+	if err != nil {
+		return model.AuditReportResponse{}, fmt.Errorf("failed to generate audit report for exam %s , err - %v", examID, err)
+	}
+	return ea.buildResponse(selectedExam, examID, roster, adj, meta, grouped)
+}
+
+func (ea *examAuditHandler) LiveAudit(ctx context.Context, instructorId, examID string) (model.AuditReportResponse, error) {
+	ia, err := ea.liveAudit(examID)
+	if err != nil {
+		return model.AuditReportResponse{}, err
+	}
+	roster, err := readRoster()
+	if err != nil {
+		return model.AuditReportResponse{}, err
+	}
+	snapshot := ia.Snapshot()
+	return ea.buildResponse(ia.Exam(), examID, roster, snapshot.Report, snapshot.Metadata, snapshot.Answers)
+}
+
+func (ea *examAuditHandler) StreamSuspicion(ctx context.Context, instructorId, examID string) (<-chan model.SuspicionEvent, error) {
+	ia, err := ea.liveAudit(examID)
+	if err != nil {
+		return nil, err
+	}
+	updates, cancel := ia.Subscribe()
+
+	// the stream ends with ctx or when the live audit stops
+	events := make(chan model.SuspicionEvent)
+	go func() {
+		defer close(events)
+		defer cancel()
+
+		var prev model.AdjacencyList
+		for {
+			next := ia.Snapshot().Report
+			for _, event := range util.DiffReports(prev, next) {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+			prev = next
+
+			select {
+			case _, ok := <-updates:
+				if !ok {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
 		}
+	}()
+	return events, nil
+}
+
+// liveAudit returns the live audit of examID, seeding it on first use
+func (ea *examAuditHandler) liveAudit(examID string) (*util.IncrementalAudit, error) {
+	ea.liveMu.Lock()
+	if entry, ok := ea.live[examID]; ok {
+		ea.liveMu.Unlock()
+		<-entry.ready
+		return entry.ia, entry.err
 	}
+	entry := &liveExam{ready: make(chan struct{})}
+	ea.live[examID] = entry
+	ea.liveMu.Unlock()
+
+	ia, cancel, events, seen, err := ea.seedLive(examID)
+
+	ea.liveMu.Lock()
+	entry.ia, entry.cancel, entry.err = ia, cancel, err
+	stopped := entry.stopped
+	if err != nil && ea.live[examID] == entry {
+		delete(ea.live, examID)
+	}
+	close(entry.ready)
+	ea.liveMu.Unlock()
 
-	students, err := util.ReadStudentsJSONData(config.Cfg.WorkingDir + "/data/students_details.json")
 	if err != nil {
-		return model.AuditReportResponse{}, fmt.Errorf("read students failed: %w", err)
+		return nil, err
+	}
+	if stopped {
+		// the exam closed while seeding
+		cancel()
+		ia.Close()
+		return ia, nil
 	}
+	go ea.follow(examID, entry, events, seen)
+	return ia, nil
+}
 
-	answers, err := ea.service.QueryEdittedAnswersByExam(selectedExam, students.Students)
+// seedLive loads examID from the ledger and starts listening right after the seeded height
+func (ea *examAuditHandler) seedLive(examID string) (*util.IncrementalAudit, context.CancelFunc, <-chan model.LedgerEvent, map[string]bool, error) {
+	height, err := ea.service.LedgerHeight()
 	if err != nil {
-		return model.AuditReportResponse{}, fmt.Errorf("failed to query editted answers by exam %s , err - %v", selectedExam.ExamID, err)
+		return nil, nil, nil, nil, fmt.Errorf("failed to start live audit for exam %s , err - %v", examID, err)
+	}
+	// a closed exam sends no state event to stop it
+	schedule, err := ea.service.QueryExamSchedule(examID)
+	if err != nil && !errors.Is(err, service.ErrExamNotScheduled) {
+		return nil, nil, nil, nil, fmt.Errorf("failed to start live audit for exam %s , err - %w", examID, err)
+	}
+	if schedule.State == model.ExamStateClosed || schedule.State == model.ExamStateAudited {
+		return nil, nil, nil, nil, fmt.Errorf("%w: exam %s is %s", ErrExamNotLive, examID, schedule.State)
 	}
 
-	grouped := util.GenerateFlattenedTable(answers)
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := ea.service.ExamEvents(ctx, height)
+	if err != nil {
+		cancel()
+		return nil, nil, nil, nil, fmt.Errorf("failed to start live audit for exam %s , err - %v", examID, err)
+	}
+	selectedExam, _, answers, err := ea.loadExamHistory(examID)
+	if err != nil {
+		cancel()
+		return nil, nil, nil, nil, err
+	}
+	ia, err := util.NewIncrementalAudit(selectedExam, util.GenerateFlattenedTable(answers))
+	if err != nil {
+		cancel()
+		return nil, nil, nil, nil, fmt.Errorf("failed to start live audit for exam %s , err - %v", examID, err)
+	}
+	seen := make(map[string]bool, len(answers))
+	for _, ans := range answers {
+		seen[ans.TxID] = true
+	}
+	return ia, cancel, events, seen, nil
+}
+
+// follow applies new answers to the live audit until the exam closes or the events end
+func (ea *examAuditHandler) follow(examID string, entry *liveExam, events <-chan model.LedgerEvent, seen map[string]bool) {
+	defer ea.stopLive(examID, entry)
+	for event := range events {
+		if event.ExamID != examID {
+			continue
+		}
+		switch event.Name {
+		case model.LedgerEventAnswerSubmitted:
+			if event.Answer == nil || seen[event.TxID] {
+				continue
+			}
+			seen[event.TxID] = true
+			entry.ia.Apply(*event.Answer)
+		case model.LedgerEventExamStateChanged:
+			if event.State == model.ExamStateClosed || event.State == model.ExamStateAudited {
+				return
+			}
+		}
+	}
+}
 
-	adj := util.GenerateAuditReport(grouped)
+// stopLive drops entry if it is still the live audit of examID and closes it
+func (ea *examAuditHandler) stopLive(examID string, entry *liveExam) {
+	ea.liveMu.Lock()
+	if ea.live[examID] == entry {
+		delete(ea.live, examID)
+	}
+	select {
+	case <-entry.ready:
+	default:
+		// the seed stops it once done
+		entry.stopped = true
+		ea.liveMu.Unlock()
+		return
+	}
+	ea.liveMu.Unlock()
+	if entry.err != nil {
+		return
+	}
+	entry.cancel()
+	entry.ia.Close()
+}
+
+func (ea *examAuditHandler) ReplayExam(ctx context.Context, instructorId, examID string, speed float64, emit func(model.TimelineEvent)) error {
+	selectedExam, _, answers, err := ea.loadExamHistory(examID)
+	if err != nil {
+		return err
+	}
+	if err := util.Replay(ctx, selectedExam, answers, speed, emit); err != nil {
+		return fmt.Errorf("failed to replay exam %s , err - %w", examID, err)
+	}
+	return nil
+}
+
+func (ea *examAuditHandler) ScheduleExam(instructorId, examID string, startTime, endTime int64) error {
+	if err := ea.service.ScheduleExam(examID, startTime, endTime); err != nil {
+		return fmt.Errorf("failed to schedule exam %s , err - %w", examID, err)
+	}
+	return nil
+}
 
-	return model.AuditReportResponse{ExamID: examID, Report: adj}, nil
+func (ea *examAuditHandler) SetExamState(instructorId, examID, state string) error {
+	if err := ea.service.SetExamState(examID, state); err != nil {
+		return fmt.Errorf("failed to move exam %s to %s , err - %w", examID, state, err)
+	}
+	if state == model.ExamStateClosed || state == model.ExamStateAudited {
+		// don't wait for the state event to stop the live audit
+		ea.liveMu.Lock()
+		entry, ok := ea.live[examID]
+		ea.liveMu.Unlock()
+		if ok {
+			ea.stopLive(examID, entry)
+		}
+	}
+	return nil
+}
+
+func (ea *examAuditHandler) GetExamSchedule(instructorId, examID string) (model.ExamSchedule, error) {
+	schedule, err := ea.service.QueryExamSchedule(examID)
+	if err != nil {
+		return model.ExamSchedule{}, fmt.Errorf("failed to read the schedule of exam %s , err - %w", examID, err)
+	}
+	return schedule, nil
+}
+
+// loadExamHistory reads the exam, its roster and the answer history of every student who submitted
+func (ea *examAuditHandler) loadExamHistory(examID string) (model.Exam, []model.Student, []model.Answer, error) {
+	exams, err := util.ReadExamJSONData(config.Cfg.WorkingDir + "/data/exam_details.json")
+	if err != nil {
+		return model.Exam{}, nil, nil, fmt.Errorf("read exam data failed: %w", err)
+	}
+	selectedExam, err := util.FindExam(exams, examID)
+	if err != nil {
+		return model.Exam{}, nil, nil, err
+	}
+
+	roster, err := readRoster()
+	if err != nil {
+		return model.Exam{}, nil, nil, err
+	}
+
+	// audit every student with answers, the roster is reconciled afterwards
+	answers, err := ea.service.QueryEdittedAnswersByExam(selectedExam, nil)
+	if err != nil {
+		return model.Exam{}, nil, nil, fmt.Errorf("failed to query editted answers by exam %s , err - %v", selectedExam.ExamID, err)
+	}
+	return selectedExam, roster, answers, nil
+}
+
+// scheduledEnd returns the end of the answer window, zero without a schedule
+func (ea *examAuditHandler) scheduledEnd(examID string) (int64, error) {
+	schedule, err := ea.service.QueryExamSchedule(examID)
+	if errors.Is(err, service.ErrExamNotScheduled) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the schedule of exam %s , err - %w", examID, err)
+	}
+	return schedule.EndTime, nil
+}
+
+func readRoster() ([]model.Student, error) {
+	students, err := util.ReadStudentsJSONData(config.Cfg.WorkingDir + "/data/students_details.json")
+	if err != nil {
+		return nil, fmt.Errorf("read students failed: %w", err)
+	}
+	return students.Students, nil
+}
+
+// buildResponse adds groups, anomalies and roster discrepancies to a pair report
+func (ea *examAuditHandler) buildResponse(exam model.Exam, examID string, roster []model.Student, adj model.AdjacencyList, meta model.ReportMetadata, grouped map[string]map[string][]model.AnswerRevision) (model.AuditReportResponse, error) {
+	resp := model.AuditReportResponse{ExamID: examID, Report: adj, Metadata: &meta}
+	if config.Cfg.Clustering.Enabled {
+		groups, err := cluster.DetectGroups(adj, config.Cfg.Clustering, config.Cfg.Scoring, config.Cfg.SuspicionScoreThreshold)
+		if err != nil {
+			return model.AuditReportResponse{}, fmt.Errorf("failed to detect collusion groups for exam %s , err - %w", examID, err)
+		}
+		resp.Groups = groups
+	}
+	if config.Cfg.Anomaly.Enabled {
+		end, err := ea.scheduledEnd(examID)
+		if err != nil {
+			return model.AuditReportResponse{}, err
+		}
+		resp.Anomalies = anomaly.Detect(exam, grouped, end, config.Cfg.Anomaly)
+	}
+
+	participants := make([]string, 0, len(grouped))
+	for sid := range grouped {
+		participants = append(participants, sid)
+	}
+	check := util.ReconcileRoster(roster, participants)
+	resp.Roster = &check
+	return resp, nil
 }
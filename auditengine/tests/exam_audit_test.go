@@ -1,6 +1,8 @@
 package audit_test
 
 import (
+	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -8,6 +10,7 @@ import (
 	"github.com/deeraj-kumar/exam-audit/config"
 	model "github.com/deeraj-kumar/exam-audit/domain"
 	"github.com/deeraj-kumar/exam-audit/service/mocks"
+	"github.com/deeraj-kumar/exam-audit/util"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -16,31 +19,33 @@ func TestAuditHandler_HighCollision(t *testing.T) {
 	if err := config.LoadConfig(); err != nil {
 		return
 	}
-	mockFabricService := new(mocks.FabricService)
+	mockFabricService := newFabricService()
 	mockAns := []model.Answer{
 		{
-			QuestionID:  "q1",
-			Ans:         "A",
+			QuestionID:  "Q1",
+			Ans:         "Option A",
 			StudentID:   "s1",
 			SubmittedAt: time.Now().Unix(),
 		},
 		{
-			QuestionID:  "q1",
-			Ans:         "C",
+			QuestionID:  "Q1",
+			Ans:         "Option C",
 			StudentID:   "s2",
 			SubmittedAt: time.Now().Unix(),
 		},
 		{
-			QuestionID:  "q1",
-			Ans:         "C",
+			QuestionID:  "Q1",
+			Ans:         "Option C",
 			StudentID:   "s1",
 			SubmittedAt: time.Now().Add(10 * time.Second).Unix(),
 		},
 	}
 	mockFabricService.On("QueryEdittedAnswersByExam", mock.Anything, mock.Anything).Return(mockAns, nil)
 	h := auditengine.NewExamAuditHandler(mockFabricService)
-	resp, err := h.AuditAnswer("1", "exam170126")
+	resp, err := h.AuditAnswer(context.Background(), "1", "exam170126")
 	assert.Nil(t, err)
+	assert.Len(t, resp.Report, 1)
+	assert.Equal(t, []string{"Q1"}, resp.Report[0].FlaggedQuestions)
 	t.Logf("report - %v", resp.Report)
 }
 
@@ -48,31 +53,31 @@ func TestAuditHandler_ModerateCollision(t *testing.T) {
 	if err := config.LoadConfig(); err != nil {
 		return
 	}
-	mockFabricService := new(mocks.FabricService)
+	mockFabricService := newFabricService()
 
 	mockAns := []model.Answer{
 		{
-			QuestionID:  "q1",
-			Ans:         "A",
+			QuestionID:  "Q1",
+			Ans:         "Option A",
 			StudentID:   "s1",
 			SubmittedAt: time.Now().Unix(),
 		},
 		{
-			QuestionID:  "q1",
-			Ans:         "C",
+			QuestionID:  "Q1",
+			Ans:         "Option C",
 			StudentID:   "s2",
 			SubmittedAt: time.Now().Unix(),
 		},
 		{
-			QuestionID:  "q1",
-			Ans:         "C",
+			QuestionID:  "Q1",
+			Ans:         "Option C",
 			StudentID:   "s1",
 			SubmittedAt: time.Now().Add(40 * time.Second).Unix(),
 		},
 	}
 	mockFabricService.On("QueryEdittedAnswersByExam", mock.Anything, mock.Anything).Return(mockAns, nil)
 	h := auditengine.NewExamAuditHandler(mockFabricService)
-	resp, err := h.AuditAnswer("1", "exam170126")
+	resp, err := h.AuditAnswer(context.Background(), "1", "exam170126")
 	assert.Nil(t, err)
 	t.Logf("report - %v", resp.Report)
 }
@@ -81,31 +86,327 @@ func TestAuditHandler_NoCollision(t *testing.T) {
 	if err := config.LoadConfig(); err != nil {
 		return
 	}
-	mockFabricService := new(mocks.FabricService)
+	mockFabricService := newFabricService()
 
 	mockAns := []model.Answer{
 		{
-			QuestionID:  "q1",
-			Ans:         "A",
+			QuestionID:  "Q1",
+			Ans:         "Option A",
 			StudentID:   "s1",
 			SubmittedAt: time.Now().Unix(),
 		},
 		{
-			QuestionID:  "q1",
-			Ans:         "C",
+			QuestionID:  "Q1",
+			Ans:         "Option C",
 			StudentID:   "s2",
 			SubmittedAt: time.Now().Unix(),
 		},
 		{
-			QuestionID:  "q1",
-			Ans:         "D",
+			QuestionID:  "Q1",
+			Ans:         "Option D",
 			StudentID:   "s1",
 			SubmittedAt: time.Now().Add(40 * time.Second).Unix(),
 		},
 	}
 	mockFabricService.On("QueryEdittedAnswersByExam", mock.Anything, mock.Anything).Return(mockAns, nil)
 	h := auditengine.NewExamAuditHandler(mockFabricService)
-	resp, err := h.AuditAnswer("1", "exam170126")
+	resp, err := h.AuditAnswer(context.Background(), "1", "exam170126")
 	assert.Nil(t, err)
 	assert.Empty(t, resp.Report)
 }
+
+func TestAuditHandler_OneRowPerPair(t *testing.T) {
+	if err := config.LoadConfig(); err != nil {
+		return
+	}
+	mockFabricService := newFabricService()
+
+	now := time.Now()
+	mockAns := []model.Answer{
+		{QuestionID: "Q1", Ans: "Option B", StudentID: "s3", SubmittedAt: now.Unix()},
+		{QuestionID: "Q1", Ans: "Option B", StudentID: "s7", SubmittedAt: now.Unix()},
+		{QuestionID: "Q3", Ans: "Option D", StudentID: "s3", SubmittedAt: now.Add(5 * time.Second).Unix()},
+		{QuestionID: "Q3", Ans: "Option D", StudentID: "s7", SubmittedAt: now.Add(5 * time.Second).Unix()},
+	}
+	mockFabricService.On("QueryEdittedAnswersByExam", mock.Anything, mock.Anything).Return(mockAns, nil)
+	h := auditengine.NewExamAuditHandler(mockFabricService)
+	resp, err := h.AuditAnswer(context.Background(), "1", "exam170126")
+	assert.Nil(t, err)
+	assert.Len(t, resp.Report, 1)
+	assert.Equal(t, []string{"Q1", "Q3"}, resp.Report[0].FlaggedQuestions)
+	assert.Len(t, resp.Report[0].Questions, 2)
+
+	reason := resp.Report[0].Reason
+	assert.NotNil(t, reason)
+	assert.NotEmpty(t, reason.Summary)
+	assert.Len(t, reason.Evidence, 2)
+	assert.Contains(t, reason.Evidence[0].SubScores, "answer_similarity")
+	assert.Len(t, reason.Evidence[0].MatchingRevisions, 1)
+}
+
+// ledgerAnswers stamps every answer with the transaction that wrote it , as the ledger history does
+func ledgerAnswers(answers []model.Answer) []model.Answer {
+	out := make([]model.Answer, len(answers))
+	for i, a := range answers {
+		a.TxID = fmt.Sprintf("seed%d", i)
+		out[i] = a
+	}
+	return out
+}
+
+// answerEvent is the AnswerSubmitted event of a transaction committing ans
+func answerEvent(examID, txID string, ans model.Answer) model.LedgerEvent {
+	ans.TxID = txID
+	return model.LedgerEvent{Name: model.LedgerEventAnswerSubmitted, TxID: txID, ExamID: examID, Answer: &ans}
+}
+
+func TestAuditHandler_LiveAudit(t *testing.T) {
+	original := config.Cfg
+	config.Cfg = goldenConfig()
+	defer func() {
+		config.Cfg = original
+	}()
+
+	seed := ledgerAnswers(goldenAnswers)
+	ledgerEvents := make(chan model.LedgerEvent)
+	var listening context.Context
+	mockFabricService := newFabricService()
+	mockFabricService.On("QueryEdittedAnswersByExam", mock.Anything, mock.Anything).Return(seed, nil)
+	mockFabricService.On("LedgerHeight").Return(uint64(42), nil)
+	mockFabricService.On("ExamEvents", mock.Anything, uint64(42)).Run(func(args mock.Arguments) {
+		listening = args.Get(0).(context.Context)
+	}).Return((<-chan model.LedgerEvent)(ledgerEvents), nil)
+	mockFabricService.On("SetExamState", "exam170126", model.ExamStateClosed).Return(nil)
+	h := auditengine.NewExamAuditHandler(mockFabricService)
+
+	full, err := h.AuditAnswer(context.Background(), "i1", "exam170126")
+	assert.Nil(t, err)
+	live, err := h.LiveAudit(context.Background(), "i1", "exam170126")
+	assert.Nil(t, err)
+	assert.Equal(t, full, live)
+
+	// a transaction the seed already holds and an answer to another exam change nothing
+	ledgerEvents <- answerEvent("exam170126", "seed3", goldenAnswers[3])
+	ledgerEvents <- answerEvent("exam999", "tx1", model.Answer{QuestionID: "Q1", Ans: "Option A", StudentID: "s9", SubmittedAt: 1700000500})
+	// s7 revising Q3 is committed after s9's answer but stamped before the revision s7 already has
+	added := []model.Answer{
+		{QuestionID: "Q3", Ans: "Option A", StudentID: "s9", SubmittedAt: 1700000520},
+		{QuestionID: "Q3", Ans: "Option C", StudentID: "s7", SubmittedAt: 1700000470},
+	}
+	ledgerEvents <- answerEvent("exam170126", "tx2", added[0])
+	ledgerEvents <- answerEvent("exam170126", "tx3", added[1])
+	// the listener takes one event at a time , once this one is taken the answers before it are applied
+	ledgerEvents <- answerEvent("exam999", "tx4", added[0])
+
+	live, err = h.LiveAudit(context.Background(), "i1", "exam170126")
+	assert.Nil(t, err)
+	mockFabricService.AssertNumberOfCalls(t, "QueryEdittedAnswersByExam", 2)
+
+	// the ledger history holds the same revisions in transaction time order
+	var recomputed []model.Answer
+	for _, a := range goldenAnswers {
+		if a.StudentID == "s7" && a.QuestionID == "Q3" {
+			recomputed = append(recomputed, added[1])
+		}
+		recomputed = append(recomputed, a)
+	}
+	recomputed = append(recomputed, added[0])
+	other := newFabricService()
+	other.On("QueryEdittedAnswersByExam", mock.Anything, mock.Anything).Return(recomputed, nil)
+	full, err = auditengine.NewExamAuditHandler(other).AuditAnswer(context.Background(), "i1", "exam170126")
+	assert.Nil(t, err)
+	assert.Equal(t, full, live)
+	assert.Equal(t, 6, live.Metadata.Students)
+
+	// closing the exam stops the listener , the next live audit seeds again
+	assert.Nil(t, h.SetExamState("i1", "exam170126", model.ExamStateClosed))
+	assert.NotNil(t, listening.Err())
+	_, err = h.LiveAudit(context.Background(), "i1", "exam170126")
+	assert.Nil(t, err)
+	mockFabricService.AssertNumberOfCalls(t, "QueryEdittedAnswersByExam", 3)
+	mockFabricService.AssertNumberOfCalls(t, "ExamEvents", 2)
+	close(ledgerEvents)
+}
+
+func TestAuditHandler_StreamSuspicion(t *testing.T) {
+	original := config.Cfg
+	config.Cfg = goldenConfig()
+	defer func() {
+		config.Cfg = original
+	}()
+
+	ledgerEvents := make(chan model.LedgerEvent)
+	mockFabricService := newFabricService()
+	mockFabricService.On("QueryEdittedAnswersByExam", mock.Anything, mock.Anything).Return(ledgerAnswers(goldenAnswers), nil)
+	mockFabricService.On("LedgerHeight").Return(uint64(7), nil)
+	mockFabricService.On("ExamEvents", mock.Anything, uint64(7)).Return((<-chan model.LedgerEvent)(ledgerEvents), nil)
+	h := auditengine.NewExamAuditHandler(mockFabricService)
+
+	events, err := h.StreamSuspicion(context.Background(), "i1", "exam170126")
+	assert.Nil(t, err)
+
+	// the current report comes first , the copied Q2 ahead of the shared rare Q3 answer
+	copied := <-events
+	assert.Equal(t, model.EventPairFlagged, copied.Type)
+	assert.Equal(t, "s3", copied.StudentA)
+	assert.Equal(t, "s7", copied.StudentB)
+	first := <-events
+	assert.Equal(t, model.EventPairFlagged, first.Type)
+	assert.Equal(t, "s4", first.StudentA)
+	assert.Equal(t, "s7", first.StudentB)
+
+	// a third student picking the same Q3 answer makes it less rare and moves the s4-s7 score
+	ledgerEvents <- answerEvent("exam170126", "tx1", model.Answer{QuestionID: "Q3", Ans: "Option A", StudentID: "s9", SubmittedAt: 1700003000})
+	next := <-events
+	assert.Contains(t, []string{model.EventScoreChanged, model.EventPairCleared}, next.Type)
+	assert.Equal(t, "s4", next.StudentA)
+	assert.Equal(t, "s7", next.StudentB)
+	assert.Equal(t, first.Score, next.PreviousScore)
+
+	// the exam closing on the ledger ends the stream
+	ledgerEvents <- model.LedgerEvent{Name: model.LedgerEventExamStateChanged, TxID: "tx2", ExamID: "exam170126", State: model.ExamStateClosed}
+	for range events {
+	}
+	close(ledgerEvents)
+}
+
+// participantsOf lists the students holding answers , in first submission order
+func participantsOf(answers []model.Answer) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, a := range answers {
+		if !seen[a.StudentID] {
+			seen[a.StudentID] = true
+			out = append(out, a.StudentID)
+		}
+	}
+	return out
+}
+
+func TestAuditHandler_OffRosterParticipant(t *testing.T) {
+	original := config.Cfg
+	config.Cfg = goldenConfig()
+	defer func() {
+		config.Cfg = original
+	}()
+
+	now := time.Now().Unix()
+	mockAns := append([]model.Answer{
+		{QuestionID: "Q1", Ans: "Option B", StudentID: "x42", SubmittedAt: now},
+	}, goldenAnswers...)
+	mockFabricService := newFabricService()
+	// no roster filter , the ledger decides who took part
+	mockFabricService.On("QueryEdittedAnswersByExam", mock.Anything, mock.MatchedBy(func(students []model.Student) bool {
+		return students == nil
+	})).Return(mockAns, nil)
+	h := auditengine.NewExamAuditHandler(mockFabricService)
+
+	resp, err := h.AuditAnswer(context.Background(), "i1", "exam170126")
+	assert.Nil(t, err)
+	assert.Equal(t, len(participantsOf(mockAns)), resp.Metadata.Students)
+	assert.Equal(t, []string{"x42"}, resp.Roster.NotOnRoster)
+	assert.NotContains(t, resp.Roster.NoSubmission, "x42")
+}
+
+func TestAuditHandler_UnknownExam(t *testing.T) {
+	original := config.Cfg
+	config.Cfg = goldenConfig()
+	defer func() {
+		config.Cfg = original
+	}()
+
+	mockFabricService := newFabricService()
+	h := auditengine.NewExamAuditHandler(mockFabricService)
+
+	_, err := h.AuditAnswer(context.Background(), "i1", "exam404")
+	assert.ErrorIs(t, err, util.ErrUnknownExam)
+	// the ledger is never asked about an exam that doesn't exist
+	mockFabricService.AssertNotCalled(t, "QueryEdittedAnswersByExam", mock.Anything, mock.Anything)
+}
+
+func TestAuditHandler_LiveAuditClosedExam(t *testing.T) {
+	original := config.Cfg
+	config.Cfg = goldenConfig()
+	defer func() {
+		config.Cfg = original
+	}()
+
+	mockFabricService := new(mocks.FabricService)
+	mockFabricService.On("LedgerHeight").Return(uint64(7), nil)
+	mockFabricService.On("QueryExamSchedule", "exam170126").Return(model.ExamSchedule{ExamID: "exam170126", State: model.ExamStateClosed}, nil)
+	h := auditengine.NewExamAuditHandler(mockFabricService)
+
+	// no state event would ever stop it , so a closed exam gets no live audit
+	_, err := h.LiveAudit(context.Background(), "i1", "exam170126")
+	assert.ErrorIs(t, err, auditengine.ErrExamNotLive)
+	mockFabricService.AssertNotCalled(t, "ExamEvents", mock.Anything, mock.Anything)
+	mockFabricService.AssertNotCalled(t, "QueryEdittedAnswersByExam", mock.Anything, mock.Anything)
+}
+
+func TestAuditHandler_LiveAuditSeedsOnce(t *testing.T) {
+	original := config.Cfg
+	config.Cfg = goldenConfig()
+	defer func() {
+		config.Cfg = original
+	}()
+
+	seeding, release := make(chan struct{}), make(chan struct{})
+	ledgerEvents := make(chan model.LedgerEvent)
+	mockFabricService := newFabricService()
+	mockFabricService.On("QueryEdittedAnswersByExam", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		close(seeding)
+		<-release
+	}).Return(ledgerAnswers(goldenAnswers), nil).Once()
+	mockFabricService.On("LedgerHeight").Return(uint64(7), nil)
+	mockFabricService.On("ExamEvents", mock.Anything, uint64(7)).Return((<-chan model.LedgerEvent)(ledgerEvents), nil)
+	h := auditengine.NewExamAuditHandler(mockFabricService)
+
+	results := make(chan model.AuditReportResponse, 2)
+	audit := func() {
+		resp, err := h.LiveAudit(context.Background(), "i1", "exam170126")
+		assert.Nil(t, err)
+		results <- resp
+	}
+	go audit()
+	<-seeding
+	// a second caller waits for the running seed instead of starting its own
+	go audit()
+	close(release)
+	assert.Equal(t, <-results, <-results)
+	mockFabricService.AssertNumberOfCalls(t, "QueryEdittedAnswersByExam", 1)
+	close(ledgerEvents)
+}
+
+func TestAuditHandler_CloseWhileSeeding(t *testing.T) {
+	original := config.Cfg
+	config.Cfg = goldenConfig()
+	defer func() {
+		config.Cfg = original
+	}()
+
+	seeding, release := make(chan struct{}), make(chan struct{})
+	var listening context.Context
+	mockFabricService := newFabricService()
+	mockFabricService.On("QueryEdittedAnswersByExam", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		close(seeding)
+		<-release
+	}).Return(ledgerAnswers(goldenAnswers), nil).Once()
+	mockFabricService.On("LedgerHeight").Return(uint64(7), nil)
+	mockFabricService.On("ExamEvents", mock.Anything, uint64(7)).Run(func(args mock.Arguments) {
+		listening = args.Get(0).(context.Context)
+	}).Return((<-chan model.LedgerEvent)(make(chan model.LedgerEvent)), nil)
+	mockFabricService.On("SetExamState", "exam170126", model.ExamStateClosed).Return(nil)
+	h := auditengine.NewExamAuditHandler(mockFabricService)
+
+	done := make(chan error)
+	go func() {
+		_, err := h.LiveAudit(context.Background(), "i1", "exam170126")
+		done <- err
+	}()
+	<-seeding
+	// closing the exam doesn't wait for the seed , the seed stops the live audit once done
+	assert.Nil(t, h.SetExamState("i1", "exam170126", model.ExamStateClosed))
+	close(release)
+	assert.Nil(t, <-done)
+	assert.NotNil(t, listening.Err())
+}
@@ -0,0 +1,111 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deeraj-kumar/exam-audit/auditengine"
+	"github.com/deeraj-kumar/exam-audit/config"
+	model "github.com/deeraj-kumar/exam-audit/domain"
+	"github.com/deeraj-kumar/exam-audit/service"
+	"github.com/deeraj-kumar/exam-audit/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenAnswers mirrors scripts/generate-ledger-data.sh with fixed timestamps : s3 and s7 copy Q2
+var goldenAnswers = []model.Answer{
+	{QuestionID: "Q1", Ans: "Option A", StudentID: "s1", SubmittedAt: 1700000040},
+	{QuestionID: "Q2", Ans: "Goroutines start with small stacks that grow as needed so thousands can run at once.", StudentID: "s1", SubmittedAt: 1700000160},
+	{QuestionID: "Q3", Ans: "Option B", StudentID: "s1", SubmittedAt: 1700000290},
+	{QuestionID: "Q4", Ans: "64", StudentID: "s1", SubmittedAt: 1700000330},
+	{QuestionID: "Q5", Ans: "go,defer,select", StudentID: "s1", SubmittedAt: 1700000370},
+	{QuestionID: "Q1", Ans: "Option C", StudentID: "s2", SubmittedAt: 1700000075},
+	{QuestionID: "Q2", Ans: "Goroutines are functions running concurrently with others in the same address space.", StudentID: "s2", SubmittedAt: 1700000230},
+	{QuestionID: "Q3", Ans: "Option B", StudentID: "s2", SubmittedAt: 1700000400},
+	{QuestionID: "Q4", Ans: "64", StudentID: "s2", SubmittedAt: 1700000440},
+	{QuestionID: "Q5", Ans: "go,defer", StudentID: "s2", SubmittedAt: 1700000500},
+	{QuestionID: "Q1", Ans: "Option D", StudentID: "s4", SubmittedAt: 1700000120},
+	{QuestionID: "Q2", Ans: "A goroutine is a lightweight thread scheduled by the Go runtime rather than the OS.", StudentID: "s4", SubmittedAt: 1700000250},
+	{QuestionID: "Q3", Ans: "Option A", StudentID: "s4", SubmittedAt: 1700000510},
+	{QuestionID: "Q4", Ans: "64", StudentID: "s4", SubmittedAt: 1700000560},
+	{QuestionID: "Q5", Ans: "defer,select", StudentID: "s4", SubmittedAt: 1700000610},
+	{QuestionID: "Q2", Ans: "Goroutines are threads.", StudentID: "s3", SubmittedAt: 1700000100},
+	{QuestionID: "Q2", Ans: "Goroutines are lightweight threads managed by the Go runtime and talk over channels.", StudentID: "s3", SubmittedAt: 1700000140},
+	{QuestionID: "Q1", Ans: "Option A", StudentID: "s3", SubmittedAt: 1700000300},
+	{QuestionID: "Q3", Ans: "Option D", StudentID: "s3", SubmittedAt: 1700000420},
+	{QuestionID: "Q4", Ans: "64", StudentID: "s3", SubmittedAt: 1700000450},
+	{QuestionID: "Q5", Ans: "go,select", StudentID: "s3", SubmittedAt: 1700000470},
+	{QuestionID: "Q2", Ans: "Goroutines are threads.", StudentID: "s7", SubmittedAt: 1700000108},
+	{QuestionID: "Q2", Ans: "Goroutines are lightweight threads managed by the Go runtime and talk over channels.", StudentID: "s7", SubmittedAt: 1700000149},
+	{QuestionID: "Q1", Ans: "Option B", StudentID: "s7", SubmittedAt: 1700000350},
+	{QuestionID: "Q3", Ans: "Option A", StudentID: "s7", SubmittedAt: 1700000480},
+	{QuestionID: "Q4", Ans: "32", StudentID: "s7", SubmittedAt: 1700000520},
+	{QuestionID: "Q5", Ans: "go,defer,select", StudentID: "s7", SubmittedAt: 1700000540},
+}
+
+func goldenConfig() model.Config {
+	var cfg model.Config
+	cfg.WorkingDir = filepath.Join("..", "..")
+	cfg.SuspicionScoreThreshold = 0.7
+	cfg.Scoring.Scorers = []model.ScorerConfig{
+		{Name: "answer_similarity", Weight: 0.5, Enabled: true},
+		{Name: "time_correlation", Weight: 0.3, Enabled: true},
+		{Name: "edit_pattern", Weight: 0.2, Enabled: true},
+		{Name: "answer_rarity", Weight: 0.3, Enabled: true},
+		{Name: "response_vector", Weight: 0.2, Enabled: true},
+		{Name: "navigation_order", Weight: 0.1, Enabled: true},
+	}
+	cfg.Clustering.Enabled = true
+	cfg.Clustering.MinSize = 2
+	cfg.Anomaly.Enabled = true
+	return cfg
+}
+
+func TestAuditHandler_GoldenReport(t *testing.T) {
+	original := config.Cfg
+	config.Cfg = goldenConfig()
+	defer func() {
+		config.Cfg = original
+	}()
+
+	golden := filepath.Join("testdata", "audit_report.golden.json")
+	var first []byte
+	for run := 0; run < 5; run++ {
+		mockFabricService := newFabricService()
+		mockFabricService.On("QueryEdittedAnswersByExam", mock.Anything, mock.Anything).Return(goldenAnswers, nil)
+		h := auditengine.NewExamAuditHandler(mockFabricService)
+
+		resp, err := h.AuditAnswer(context.Background(), "i1", "exam170126")
+		assert.Nil(t, err)
+		got, err := json.MarshalIndent(resp, "", "  ")
+		assert.Nil(t, err)
+		got = append(got, '\n')
+
+		if run == 0 {
+			first = got
+			continue
+		}
+		assert.Equal(t, string(first), string(got), "report differs between runs")
+	}
+
+	if *update {
+		assert.Nil(t, os.WriteFile(golden, first, 0o644))
+	}
+	want, err := os.ReadFile(golden)
+	assert.Nil(t, err)
+	assert.Equal(t, string(want), string(first))
+}
+
+// newFabricService returns a ledger mock for an exam without a schedule , like the legacy exam of the fixtures
+func newFabricService() *mocks.FabricService {
+	m := new(mocks.FabricService)
+	m.On("QueryExamSchedule", mock.Anything).Return(model.ExamSchedule{}, service.ErrExamNotScheduled)
+	return m
+}
@@ -0,0 +1,243 @@
+package candidate
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"sort"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+	"github.com/deeraj-kumar/exam-audit/scoring"
+)
+
+const (
+	defaultMinStudents         = 500
+	defaultRecall              = 0.95
+	defaultSimilarityThreshold = 0.5
+	defaultNumHashes           = 128
+	defaultRareAnswerShare     = 0.05
+	defaultMaxBucketSize       = 200
+)
+
+// Candidates lists for every student index i the indexes j > i worth a full comparison , both sorted .
+// A nil Candidates means no pruning took place and every pair should be scored
+type Candidates [][]int
+
+// Count returns the number of candidate pairs
+func (c Candidates) Count() int {
+	var n int
+	for _, row := range c {
+		n += len(row)
+	}
+	return n
+}
+
+// Select buckets students with MinHash signatures of the answers that can tell them apart , the wrong answers
+// to keyed questions and the answers few students ever held . Signatures are split into bands and any pair
+// sharing a band becomes a candidate . Bands are sized as narrow as possible while keeping the configured recall
+// at the similarity threshold . A band bucket holding more than MaxBucketSize students is a shared habit rather
+// than evidence and is skipped , and students without any telling answer are never candidates . Returns nil when
+// pruning is disabled or the cohort is too small to be worth it
+func Select(exam model.Exam, studentIDs []string, studentAnswersMap map[string]map[string][]model.AnswerRevision, cfg model.PruningConfig) Candidates {
+	cfg = withDefaults(cfg)
+	if !cfg.Enabled || len(studentIDs) < cfg.MinStudents {
+		return nil
+	}
+
+	bands, rows := Bands(cfg.NumHashes, cfg.SimilarityThreshold, cfg.Recall)
+	seeds := hashSeeds(cfg.Seed, bands*rows)
+	telling := tellingAnswers(exam, studentIDs, studentAnswersMap, cfg.RareAnswerShare)
+
+	buckets := make(map[uint64][]int)
+	for i, sid := range studentIDs {
+		tokens := answerTokens(studentAnswersMap[sid], telling)
+		if len(tokens) == 0 {
+			continue
+		}
+		sig := signature(tokens, seeds)
+		for b := 0; b < bands; b++ {
+			key := bandKey(b, sig[b*rows:(b+1)*rows])
+			buckets[key] = append(buckets[key], i)
+		}
+	}
+
+	partners := make([]map[int]struct{}, len(studentIDs))
+	for _, members := range buckets {
+		if len(members) > cfg.MaxBucketSize {
+			continue
+		}
+		for x := 0; x < len(members); x++ {
+			for y := x + 1; y < len(members); y++ {
+				i, j := members[x], members[y]
+				if partners[i] == nil {
+					partners[i] = make(map[int]struct{})
+				}
+				partners[i][j] = struct{}{}
+			}
+		}
+	}
+
+	out := make(Candidates, len(studentIDs))
+	for i, set := range partners {
+		for j := range set {
+			out[i] = append(out[i], j)
+		}
+		sort.Ints(out[i])
+	}
+	return out
+}
+
+// Bands picks the band count and rows per band for numHashes hash functions . More rows per band prune
+// more pairs , so the widest band that still collides with probability recall at similarity is chosen
+func Bands(numHashes int, similarity, recall float64) (int, int) {
+	for rows := numHashes; rows > 1; rows-- {
+		bands := numHashes / rows
+		if CollisionProbability(similarity, bands, rows) >= recall {
+			return bands, rows
+		}
+	}
+	return numHashes, 1
+}
+
+// CollisionProbability is the chance that two sets with the given Jaccard similarity share at least one band
+func CollisionProbability(similarity float64, bands, rows int) float64 {
+	return 1 - math.Pow(1-math.Pow(similarity, float64(rows)), float64(bands))
+}
+
+func withDefaults(cfg model.PruningConfig) model.PruningConfig {
+	if cfg.MinStudents <= 0 {
+		cfg.MinStudents = defaultMinStudents
+	}
+	if cfg.Recall <= 0 || cfg.Recall > 1 {
+		cfg.Recall = defaultRecall
+	}
+	if cfg.SimilarityThreshold <= 0 || cfg.SimilarityThreshold > 1 {
+		cfg.SimilarityThreshold = defaultSimilarityThreshold
+	}
+	if cfg.NumHashes <= 0 {
+		cfg.NumHashes = defaultNumHashes
+	}
+	if cfg.RareAnswerShare <= 0 || cfg.RareAnswerShare > 1 {
+		cfg.RareAnswerShare = defaultRareAnswerShare
+	}
+	if cfg.MaxBucketSize <= 0 {
+		cfg.MaxBucketSize = defaultMaxBucketSize
+	}
+	return cfg
+}
+
+// tellingAnswers returns the tokens of every (question , normalized answer) that is wrong for a keyed question
+// or was held at some point by at most rareShare of the students answering the question
+func tellingAnswers(exam model.Exam, studentIDs []string, studentAnswersMap map[string]map[string][]model.AnswerRevision, rareShare float64) map[uint64]bool {
+	questions := make(map[string]model.Question, len(exam.Questions))
+	for _, q := range exam.Questions {
+		questions[q.QuestionID] = q
+	}
+
+	type heldAnswer struct {
+		qID      string
+		students int
+		wrong    bool
+	}
+	held := make(map[uint64]*heldAnswer)
+	answered := make(map[string]int)
+	for _, sid := range studentIDs {
+		for qID, revisions := range studentAnswersMap[sid] {
+			if len(revisions) == 0 {
+				continue
+			}
+			answered[qID]++
+			seen := make(map[uint64]bool, len(revisions))
+			for _, r := range revisions {
+				t := token(qID, scoring.Normalize(r.Ans))
+				if seen[t] {
+					continue
+				}
+				seen[t] = true
+				h, ok := held[t]
+				if !ok {
+					q, keyed := questions[qID]
+					h = &heldAnswer{qID: qID, wrong: keyed && q.CorrectAnswer != "" && !scoring.IsCorrect(q, r.Ans)}
+					held[t] = h
+				}
+				h.students++
+			}
+		}
+	}
+
+	telling := make(map[uint64]bool, len(held))
+	for t, h := range held {
+		if h.wrong || float64(h.students) <= rareShare*float64(answered[h.qID]) {
+			telling[t] = true
+		}
+	}
+	return telling
+}
+
+// answerTokens hashes every distinct telling (question , normalized answer) a student ever held
+func answerTokens(answers map[string][]model.AnswerRevision, telling map[uint64]bool) []uint64 {
+	var out []uint64
+	for qID, revisions := range answers {
+		for _, r := range revisions {
+			if t := token(qID, scoring.Normalize(r.Ans)); telling[t] {
+				out = append(out, t)
+			}
+		}
+	}
+	return out
+}
+
+func token(qID, value string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(qID))
+	h.Write([]byte{0})
+	h.Write([]byte(value))
+	return h.Sum64()
+}
+
+// signature is the MinHash of tokens under one hash function per seed . Empty sets keep the maximum
+// value everywhere and so only collide with each other
+func signature(tokens []uint64, seeds []uint64) []uint64 {
+	sig := make([]uint64, len(seeds))
+	for k := range sig {
+		sig[k] = math.MaxUint64
+	}
+	for _, t := range tokens {
+		for k, seed := range seeds {
+			sig[k] = min(sig[k], mix(t^seed))
+		}
+	}
+	return sig
+}
+
+func bandKey(band int, values []uint64) uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(band))
+	h.Write(buf[:])
+	for _, v := range values {
+		binary.LittleEndian.PutUint64(buf[:], v)
+		h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+func hashSeeds(seed uint64, n int) []uint64 {
+	seeds := make([]uint64, n)
+	state := seed
+	for k := range seeds {
+		state += 0x9e3779b97f4a7c15
+		seeds[k] = mix(state)
+	}
+	return seeds
+}
+
+// mix is the splitmix64 finalizer , a cheap bijective 64 bit hash
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,135 @@
+package candidate_test
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/deeraj-kumar/exam-audit/candidate"
+	model "github.com/deeraj-kumar/exam-audit/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+// cohort builds students with free text answers unique to each of them , spread over the day , plus s000
+// and s001 who share every answer within seconds of each other
+func cohort(students, questions int) ([]string, map[string]map[string][]model.AnswerRevision) {
+	rng := rand.New(rand.NewSource(7))
+	answers := make(map[string]map[string][]model.AnswerRevision)
+	var ids []string
+	for s := 0; s < students; s++ {
+		sid := fmt.Sprintf("s%03d", s)
+		ids = append(ids, sid)
+		answers[sid] = make(map[string][]model.AnswerRevision)
+		start := int64(rng.Intn(86400))
+		for q := 0; q < questions; q++ {
+			answers[sid][fmt.Sprintf("q%d", q)] = []model.AnswerRevision{{SubmittedAt: start + int64(q*120), Ans: fmt.Sprintf("answer %d by %s", q, sid)}}
+		}
+	}
+	for q := 0; q < questions; q++ {
+		qID := fmt.Sprintf("q%d", q)
+		src := answers["s000"][qID][0]
+		answers["s001"][qID] = []model.AnswerRevision{{SubmittedAt: src.SubmittedAt + 5, Ans: src.Ans}}
+	}
+	return ids, answers
+}
+
+func TestSelect_KeepsCopyingPairAndPrunesTheRest(t *testing.T) {
+	ids, answers := cohort(80, 10)
+	cfg := model.PruningConfig{Enabled: true, MinStudents: 2, Recall: 0.95, Seed: 3}
+
+	candidates := candidate.Select(model.Exam{}, ids, answers, cfg)
+	assert.Len(t, candidates, len(ids))
+	assert.True(t, slices.Contains(candidates[0], 1))
+
+	total := len(ids) * (len(ids) - 1) / 2
+	assert.Less(t, candidates.Count(), total/10)
+	for i, row := range candidates {
+		assert.True(t, slices.IsSorted(row))
+		for _, j := range row {
+			assert.Greater(t, j, i)
+		}
+	}
+
+	// the same seed gives the same candidates
+	assert.Equal(t, candidates, candidate.Select(model.Exam{}, ids, answers, cfg))
+}
+
+// mcqCohort builds students sitting a multiple choice exam in lockstep , every question answered at the same
+// second by everyone and answered correctly seven times out of ten . s0000 and s0001 share every answer
+func mcqCohort(students, questions int) (model.Exam, []string, map[string]map[string][]model.AnswerRevision) {
+	rng := rand.New(rand.NewSource(11))
+	options := []string{"A", "B", "C", "D"}
+	exam := model.Exam{ExamID: "sync"}
+	for q := 0; q < questions; q++ {
+		exam.Questions = append(exam.Questions, model.Question{QuestionID: fmt.Sprintf("q%d", q), Type: "mcq", Options: options, CorrectAnswer: options[q%4]})
+	}
+
+	answers := make(map[string]map[string][]model.AnswerRevision)
+	var ids []string
+	for s := 0; s < students; s++ {
+		sid := fmt.Sprintf("s%04d", s)
+		ids = append(ids, sid)
+		answers[sid] = make(map[string][]model.AnswerRevision)
+		for q, question := range exam.Questions {
+			ans := question.CorrectAnswer
+			if rng.Float64() >= 0.7 {
+				ans = options[(q+1+rng.Intn(3))%4]
+			}
+			answers[sid][question.QuestionID] = []model.AnswerRevision{{SubmittedAt: int64(1000 + q*60), Ans: ans}}
+		}
+	}
+	answers["s0001"] = answers["s0000"]
+	return exam, ids, answers
+}
+
+func TestSelect_SynchronousMCQCohort(t *testing.T) {
+	exam, ids, answers := mcqCohort(2000, 20)
+	cfg := model.PruningConfig{Enabled: true, MinStudents: 2, Recall: 0.95, Seed: 3}
+
+	candidates := candidate.Select(exam, ids, answers, cfg)
+	assert.True(t, slices.Contains(candidates[0], 1))
+
+	// identical timing and correct answers shared by most of the cohort are no reason to compare a pair
+	total := len(ids) * (len(ids) - 1) / 2
+	pruned := 1 - float64(candidates.Count())/float64(total)
+	t.Logf("kept %d of %d pairs , pruned %.4f", candidates.Count(), total, pruned)
+	assert.Greater(t, pruned, 0.95)
+}
+
+func TestSelect_SkipsCrowdedBuckets(t *testing.T) {
+	exam, ids, answers := mcqCohort(300, 20)
+	// everyone giving the same wrong answer to q0 and nothing else wrong puts the whole cohort in every bucket
+	for _, sid := range ids {
+		for _, q := range exam.Questions {
+			answers[sid][q.QuestionID] = []model.AnswerRevision{{SubmittedAt: 1000, Ans: q.CorrectAnswer}}
+		}
+		answers[sid]["q0"] = []model.AnswerRevision{{SubmittedAt: 1000, Ans: "B"}}
+	}
+	cfg := model.PruningConfig{Enabled: true, MinStudents: 2, Seed: 3, MaxBucketSize: 100}
+	assert.Zero(t, candidate.Select(exam, ids, answers, cfg).Count())
+
+	cfg.MaxBucketSize = len(ids)
+	assert.Equal(t, len(ids)*(len(ids)-1)/2, candidate.Select(exam, ids, answers, cfg).Count())
+}
+
+func TestSelect_Disabled(t *testing.T) {
+	ids, answers := cohort(10, 3)
+	assert.Nil(t, candidate.Select(model.Exam{}, ids, answers, model.PruningConfig{}))
+	assert.Nil(t, candidate.Select(model.Exam{}, ids, answers, model.PruningConfig{Enabled: true, MinStudents: 20}))
+}
+
+func TestBands_MeetRecall(t *testing.T) {
+	for _, recall := range []float64{0.5, 0.9, 0.99} {
+		bands, rows := candidate.Bands(128, 0.5, recall)
+		assert.LessOrEqual(t, bands*rows, 128)
+		assert.GreaterOrEqual(t, candidate.CollisionProbability(0.5, bands, rows), recall)
+		// one more row per band would drop below the requested recall
+		assert.Less(t, candidate.CollisionProbability(0.5, 128/(rows+1), rows+1), recall)
+	}
+
+	// asking for more recall never narrows the bands
+	_, loose := candidate.Bands(128, 0.5, 0.5)
+	_, strict := candidate.Bands(128, 0.5, 0.99)
+	assert.GreaterOrEqual(t, loose, strict)
+}
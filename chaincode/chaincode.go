@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
@@ -14,6 +17,8 @@ type AnswerContract struct {
 
 type Answer struct {
 	AnsString string `json:"ans"`
+	// Migrated marks the write that moved an answer from its legacy key , it is not a revision by the student
+	Migrated bool `json:"migrated,omitempty"`
 }
 
 type AnswerSubmissionDetail struct {
@@ -23,6 +28,88 @@ type AnswerSubmissionDetail struct {
 	IsDelete  bool   `json:"isDelete"`
 }
 
+// answerObjectType prefixes the composite key (examID , questionID , studentID) of every answer
+const answerObjectType = "Answer"
+
+// legacyKeySeparator joined the parts of the plain Answer~examID~questionID~studentID keys written before
+// composite keys were used
+const legacyKeySeparator = "~"
+
+// examObjectType prefixes the composite key (examID) of every exam schedule
+const examObjectType = "Exam"
+
+// roleAttribute is the certificate attribute naming the role of the caller , only instructors and admins
+// may schedule exams , move them between states or migrate their answers
+const (
+	roleAttribute  = "role"
+	roleInstructor = "instructor"
+	roleAdmin      = "admin"
+)
+
+// Exam states , an exam only ever moves one step forward
+const (
+	ExamDraft   = "draft"
+	ExamOpen    = "open"
+	ExamClosed  = "closed"
+	ExamAudited = "audited"
+)
+
+// examTransitions maps every exam state to the only state it can move to
+var examTransitions = map[string]string{
+	ExamDraft:  ExamOpen,
+	ExamOpen:   ExamClosed,
+	ExamClosed: ExamAudited,
+}
+
+// Chaincode events , every committed answer and every lifecycle move is announced so clients can follow exams live
+const (
+	answerSubmittedEvent  = "AnswerSubmitted"
+	examStateChangedEvent = "ExamStateChanged"
+)
+
+// AnswerEvent is the payload of AnswerSubmitted , SubmittedAt is the transaction time the ledger records
+type AnswerEvent struct {
+	ExamID string            `json:"examID"`
+	Answer AnswerEventAnswer `json:"answer"`
+}
+
+type AnswerEventAnswer struct {
+	QuestionID  string `json:"questionID"`
+	StudentID   string `json:"studentID"`
+	Ans         string `json:"ans"`
+	SubmittedAt int64  `json:"submittedAt"`
+}
+
+// ExamStateEvent is the payload of ExamStateChanged
+type ExamStateEvent struct {
+	ExamID string `json:"examID"`
+	State  string `json:"state"`
+}
+
+// answersRejected starts every error of SetAnswer refusing an answer because of the exam schedule
+const answersRejected = "does not accept answers"
+
+// Exam is the answer window and lifecycle state of an exam , times are unix seconds and the window is [start , end)
+type Exam struct {
+	ExamID    string `json:"examID"`
+	StartTime int64  `json:"startTime"`
+	EndTime   int64  `json:"endTime"`
+	State     string `json:"state"`
+}
+
+// ExamAnswerHistory is the revision history of one student's answer to one question
+type ExamAnswerHistory struct {
+	QuestionID string                   `json:"questionID"`
+	StudentID  string                   `json:"studentID"`
+	Revisions  []AnswerSubmissionDetail `json:"revisions"`
+}
+
+type ExamAnswerHistoryPage struct {
+	Records             []ExamAnswerHistory `json:"records"`
+	Bookmark            string              `json:"bookmark"`
+	FetchedRecordsCount int32               `json:"fetchedRecordsCount"`
+}
+
 func (t *AnswerContract) SubmissionExists(ctx contractapi.TransactionContextInterface, key string) (bool, error) {
 	assetBytes, err := ctx.GetStub().GetState(key)
 	if err != nil {
@@ -32,9 +119,16 @@ func (t *AnswerContract) SubmissionExists(ctx contractapi.TransactionContextInte
 	return assetBytes != nil, nil
 }
 
-func (c *AnswerContract) SetAnswer(ctx contractapi.TransactionContextInterface, key string, answer string) error {
-	if key == "" {
-		return fmt.Errorf("key cannot be empty")
+// SetAnswer records an answer while its exam is open and the transaction falls within the answer window .
+// The transaction timestamp is used rather than the client's clock , so a late edit can't be backdated
+func (c *AnswerContract) SetAnswer(ctx contractapi.TransactionContextInterface, examID, questionID, studentID string, answer string) error {
+	key, err := answerKey(ctx, examID, questionID, studentID)
+	if err != nil {
+		return err
+	}
+	at, err := checkAnswerWindow(ctx, examID)
+	if err != nil {
+		return err
 	}
 
 	ans := &Answer{
@@ -44,49 +138,432 @@ func (c *AnswerContract) SetAnswer(ctx contractapi.TransactionContextInterface,
 	if err != nil {
 		return err
 	}
+	if err := ctx.GetStub().PutState(key, bytes); err != nil {
+		return err
+	}
 
-	return ctx.GetStub().PutState(key, bytes)
+	return setEvent(ctx, answerSubmittedEvent, AnswerEvent{
+		ExamID: examID,
+		Answer: AnswerEventAnswer{QuestionID: questionID, StudentID: studentID, Ans: answer, SubmittedAt: at},
+	})
 }
 
 func (c *AnswerContract) GetAnswerRevisionHistory(
-	ctx contractapi.TransactionContextInterface, key string) ([]AnswerSubmissionDetail, error) {
-	if key == "" {
-		return nil, fmt.Errorf("key cannot be empty")
+	ctx contractapi.TransactionContextInterface, examID, questionID, studentID string) ([]AnswerSubmissionDetail, error) {
+	key, err := answerKey(ctx, examID, questionID, studentID)
+	if err != nil {
+		return nil, err
 	}
 
-	log.Printf("GetAnswerRevisionHistory : Key - %s", key)
+	log.Printf("GetAnswerRevisionHistory : exam - %s , question - %s , student - %s", examID, questionID, studentID)
 
 	exists, err := c.SubmissionExists(ctx, key)
 	if err != nil {
 		return nil, err
 	}
+	if exists {
+		return answerHistory(ctx, key, legacyAnswerKey(examID, questionID, studentID))
+	}
 
+	// not migrated yet , answer from the legacy key
+	legacyKey := legacyAnswerKey(examID, questionID, studentID)
+	exists, err = c.SubmissionExists(ctx, legacyKey)
+	if err != nil {
+		return nil, err
+	}
 	if !exists {
-		return nil, fmt.Errorf("key %s does not have a world state existing in the ledger", key)
+		// the student skipped the question , nothing was ever submitted
+		return []AnswerSubmissionDetail{}, nil
+	}
+	history, _, err := keyHistory(ctx, legacyKey)
+	return history, err
+}
+
+// MigrateLegacyAnswers moves up to limit answers of an exam from plain Answer~examID~questionID~studentID keys to
+// composite keys and returns how many were moved . Migrated legacy keys are deleted , so calling it again picks up
+// where the previous call stopped , until it returns zero . The revision history stays on the legacy key and is
+// merged back in by the history queries
+func (c *AnswerContract) MigrateLegacyAnswers(ctx contractapi.TransactionContextInterface, examID string, limit int32) (int, error) {
+	if err := requireInstructor(ctx); err != nil {
+		return 0, err
+	}
+	if err := validateKeyPart("exam id", examID); err != nil {
+		return 0, err
+	}
+	if limit <= 0 {
+		return 0, fmt.Errorf("limit must be positive , got %d", limit)
+	}
+
+	prefix := legacyAnswerPrefix(examID)
+	iter, err := ctx.GetStub().GetStateByRange(prefix, legacyAnswerRangeEnd(examID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to query legacy answers of exam %s: %v", examID, err)
+	}
+	defer iter.Close()
+
+	migrated := 0
+	for iter.HasNext() && migrated < int(limit) {
+		kv, err := iter.Next()
+		if err != nil {
+			return migrated, fmt.Errorf("failed iterating legacy answers: %v", err)
+		}
+		// question ids never held the separator , anything after the first one belongs to the student id
+		parts := strings.SplitN(strings.TrimPrefix(kv.Key, prefix), legacyKeySeparator, 2)
+		if len(parts) != 2 {
+			return migrated, fmt.Errorf("legacy answer key %s is malformed", kv.Key)
+		}
+		key, err := answerKey(ctx, examID, parts[0], parts[1])
+		if err != nil {
+			return migrated, fmt.Errorf("legacy answer key %s can't be migrated: %v", kv.Key, err)
+		}
+
+		// an answer already written under the composite key is newer than the legacy one
+		current, err := ctx.GetStub().GetState(key)
+		if err != nil {
+			return migrated, fmt.Errorf("failed to read asset %s from world state. %v", key, err)
+		}
+		if current == nil {
+			current = kv.Value
+		}
+		var ans Answer
+		if err := json.Unmarshal(current, &ans); err != nil {
+			return migrated, err
+		}
+		ans.Migrated = true
+		bytes, err := json.Marshal(ans)
+		if err != nil {
+			return migrated, err
+		}
+		if err := ctx.GetStub().PutState(key, bytes); err != nil {
+			return migrated, err
+		}
+		if err := ctx.GetStub().DelState(kv.Key); err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+	return migrated, nil
+}
+
+// GetExamAnswerHistory returns the revision history of every answer of an exam one page at a time . Answers under
+// the composite key prefix (Answer , examID) come first , followed by the answers not migrated yet from legacy
+// keys , so an exam in the middle of a migration is read whole . Pass the returned bookmark to fetch the next page
+// until it comes back empty
+func (c *AnswerContract) GetExamAnswerHistory(
+	ctx contractapi.TransactionContextInterface, examID string, pageSize int32, bookmark string) (*ExamAnswerHistoryPage, error) {
+	if err := validateKeyPart("exam id", examID); err != nil {
+		return nil, err
+	}
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("page size must be positive , got %d", pageSize)
+	}
+
+	// legacy keys sort apart from composite keys , which start with a zero byte , so the bookmark tells the phases apart
+	if strings.HasPrefix(bookmark, legacyAnswerPrefix(examID)) {
+		return legacyAnswerPage(ctx, examID, pageSize, bookmark)
+	}
+
+	iter, meta, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(answerObjectType, []string{examID}, pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query answers of exam %s: %v", examID, err)
+	}
+	defer iter.Close()
+
+	page := &ExamAnswerHistoryPage{Records: []ExamAnswerHistory{}}
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed iterating answers: %v", err)
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split key %s: %v", kv.Key, err)
+		}
+		if len(attributes) != 3 {
+			return nil, fmt.Errorf("answer key %s has %d attributes , expected 3", kv.Key, len(attributes))
+		}
+
+		revisions, err := answerHistory(ctx, kv.Key, legacyAnswerKey(attributes[0], attributes[1], attributes[2]))
+		if err != nil {
+			return nil, err
+		}
+		page.Records = append(page.Records, ExamAnswerHistory{
+			QuestionID: attributes[1],
+			StudentID:  attributes[2],
+			Revisions:  revisions,
+		})
+	}
+	if meta != nil {
+		page.Bookmark = meta.Bookmark
+		page.FetchedRecordsCount = meta.FetchedRecordsCount
+	}
+	if meta == nil || meta.Bookmark == "" || meta.FetchedRecordsCount < pageSize {
+		// composite keys are exhausted , continue with the legacy range
+		page.Bookmark = legacyAnswerPrefix(examID)
+	}
+	return page, nil
+}
+
+// legacyAnswerPage reads one page of answers still under plain Answer~examID~questionID~studentID keys starting
+// at bookmark , the returned bookmark is empty once the range is exhausted
+func legacyAnswerPage(ctx contractapi.TransactionContextInterface, examID string, pageSize int32, bookmark string) (*ExamAnswerHistoryPage, error) {
+	prefix := legacyAnswerPrefix(examID)
+	iter, meta, err := ctx.GetStub().GetStateByRangeWithPagination(prefix, legacyAnswerRangeEnd(examID), pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query legacy answers of exam %s: %v", examID, err)
 	}
+	defer iter.Close()
 
+	page := &ExamAnswerHistoryPage{Records: []ExamAnswerHistory{}}
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed iterating legacy answers: %v", err)
+		}
+		parts := strings.SplitN(strings.TrimPrefix(kv.Key, prefix), legacyKeySeparator, 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("legacy answer key %s is malformed", kv.Key)
+		}
+		revisions, _, err := keyHistory(ctx, kv.Key)
+		if err != nil {
+			return nil, err
+		}
+		page.Records = append(page.Records, ExamAnswerHistory{
+			QuestionID: parts[0],
+			StudentID:  parts[1],
+			Revisions:  revisions,
+		})
+	}
+	if meta != nil {
+		page.FetchedRecordsCount = meta.FetchedRecordsCount
+		if meta.FetchedRecordsCount >= pageSize && strings.HasPrefix(meta.Bookmark, prefix) {
+			page.Bookmark = meta.Bookmark
+		}
+	}
+	return page, nil
+}
+
+// CreateExam schedules a new exam in the draft state with answers accepted from startTime until endTime
+func (c *AnswerContract) CreateExam(ctx contractapi.TransactionContextInterface, examID string, startTime, endTime int64) error {
+	if err := requireInstructor(ctx); err != nil {
+		return err
+	}
+	key, err := examKey(ctx, examID)
+	if err != nil {
+		return err
+	}
+	if endTime <= startTime {
+		return fmt.Errorf("exam %s must end after it starts , start %d , end %d", examID, startTime, endTime)
+	}
+	exists, err := c.SubmissionExists(ctx, key)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("exam %s already exists", examID)
+	}
+	return putExam(ctx, key, &Exam{ExamID: examID, StartTime: startTime, EndTime: endTime, State: ExamDraft})
+}
+
+// GetExam returns the schedule and state of an exam
+func (c *AnswerContract) GetExam(ctx contractapi.TransactionContextInterface, examID string) (*Exam, error) {
+	key, err := examKey(ctx, examID)
+	if err != nil {
+		return nil, err
+	}
+	return getExam(ctx, key, examID)
+}
+
+// OpenExam starts accepting answers for a draft exam , within its window
+func (c *AnswerContract) OpenExam(ctx contractapi.TransactionContextInterface, examID string) error {
+	return transitionExam(ctx, examID, ExamOpen)
+}
+
+// CloseExam stops accepting answers for an open exam , even before its window ends
+func (c *AnswerContract) CloseExam(ctx contractapi.TransactionContextInterface, examID string) error {
+	return transitionExam(ctx, examID, ExamClosed)
+}
+
+// MarkExamAudited records that the audit of a closed exam is done
+func (c *AnswerContract) MarkExamAudited(ctx contractapi.TransactionContextInterface, examID string) error {
+	return transitionExam(ctx, examID, ExamAudited)
+}
+
+func transitionExam(ctx contractapi.TransactionContextInterface, examID, to string) error {
+	if err := requireInstructor(ctx); err != nil {
+		return err
+	}
+	key, err := examKey(ctx, examID)
+	if err != nil {
+		return err
+	}
+	exam, err := getExam(ctx, key, examID)
+	if err != nil {
+		return err
+	}
+	if examTransitions[exam.State] != to {
+		return fmt.Errorf("exam %s can't move from %s to %s", examID, exam.State, to)
+	}
+	exam.State = to
+	if err := putExam(ctx, key, exam); err != nil {
+		return err
+	}
+	return setEvent(ctx, examStateChangedEvent, ExamStateEvent{ExamID: examID, State: to})
+}
+
+// requireInstructor rejects callers whose certificate carries neither the instructor nor the admin role
+func requireInstructor(ctx contractapi.TransactionContextInterface) error {
+	identity := ctx.GetClientIdentity()
+	if identity == nil {
+		return fmt.Errorf("caller identity is unknown")
+	}
+	for _, role := range []string{roleInstructor, roleAdmin} {
+		if identity.AssertAttributeValue(roleAttribute, role) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("caller may not manage exams , its %s attribute must be %s or %s", roleAttribute, roleInstructor, roleAdmin)
+}
+
+func setEvent(ctx contractapi.TransactionContextInterface, name string, payload interface{}) error {
+	bytes, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().SetEvent(name, bytes)
+}
+
+// checkAnswerWindow rejects answers to an exam that is not open or outside its window at the transaction time ,
+// which it returns in unix seconds
+func checkAnswerWindow(ctx contractapi.TransactionContextInterface, examID string) (int64, error) {
+	key, err := examKey(ctx, examID)
+	if err != nil {
+		return 0, err
+	}
+	exam, err := readExam(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if exam == nil {
+		return 0, fmt.Errorf("exam %s %s , it is not scheduled", examID, answersRejected)
+	}
+	if exam.State != ExamOpen {
+		return 0, fmt.Errorf("exam %s %s , it is %s", examID, answersRejected, exam.State)
+	}
+
+	ts, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the transaction timestamp: %v", err)
+	}
+	now := ts.GetSeconds()
+	if now < exam.StartTime || now >= exam.EndTime {
+		return 0, fmt.Errorf("exam %s %s at %d , its window is %d to %d", examID, answersRejected, now, exam.StartTime, exam.EndTime)
+	}
+	return now, nil
+}
+
+func getExam(ctx contractapi.TransactionContextInterface, key, examID string) (*Exam, error) {
+	exam, err := readExam(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if exam == nil {
+		return nil, fmt.Errorf("exam %s does not exist", examID)
+	}
+	return exam, nil
+}
+
+// readExam returns the exam stored under key , nil when there is none
+func readExam(ctx contractapi.TransactionContextInterface, key string) (*Exam, error) {
+	bytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read asset %s from world state. %v", key, err)
+	}
+	if bytes == nil {
+		return nil, nil
+	}
+	var exam Exam
+	if err := json.Unmarshal(bytes, &exam); err != nil {
+		return nil, err
+	}
+	return &exam, nil
+}
+
+func putExam(ctx contractapi.TransactionContextInterface, key string, exam *Exam) error {
+	bytes, err := json.Marshal(exam)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, bytes)
+}
+
+func examKey(ctx contractapi.TransactionContextInterface, examID string) (string, error) {
+	if err := validateKeyPart("exam id", examID); err != nil {
+		return "", err
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(examObjectType, []string{examID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create exam key: %v", err)
+	}
+	return key, nil
+}
+
+// answerHistory reads the revisions of an answer . When the answer was migrated from a legacy key the revisions
+// written under that key come first
+func answerHistory(ctx contractapi.TransactionContextInterface, key, legacyKey string) ([]AnswerSubmissionDetail, error) {
+	history, migrated, err := keyHistory(ctx, key)
+	if err != nil || !migrated {
+		return history, err
+	}
+
+	legacy, _, err := keyHistory(ctx, legacyKey)
+	if err != nil {
+		return nil, err
+	}
+	merged := make([]AnswerSubmissionDetail, 0, len(legacy)+len(history))
+	for _, record := range legacy {
+		// the migration deleted the legacy key , that is not a revision either
+		if !record.IsDelete {
+			merged = append(merged, record)
+		}
+	}
+	merged = append(merged, history...)
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Timestamp < merged[j].Timestamp })
+	return merged, nil
+}
+
+// keyHistory reads every revision ever written to key , oldest first , leaving out migration writes . The
+// returned flag reports whether any migration write was seen . Fabric returns history newest first , so the
+// revisions are ordered by their transaction time
+func keyHistory(ctx contractapi.TransactionContextInterface, key string) ([]AnswerSubmissionDetail, bool, error) {
 	// Get iterator for full history
 	iter, err := ctx.GetStub().GetHistoryForKey(key)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve history: %v", err)
+		return nil, false, fmt.Errorf("failed to retrieve history: %v", err)
 	}
 	defer iter.Close()
 
 	var submissionRecord []AnswerSubmissionDetail
+	var nanos []int64
+	migrated := false
 
 	for iter.HasNext() {
 		resp, err := iter.Next()
 		if err != nil {
-			return nil, fmt.Errorf("failed iterating history: %v", err)
+			return nil, false, fmt.Errorf("failed iterating history: %v", err)
 		}
 
 		var answer Answer
 		if len(resp.Value) > 0 {
 			err = json.Unmarshal(resp.Value, &answer)
 			if err != nil {
-				return nil, err
+				return nil, false, err
 			}
 		}
+		if answer.Migrated {
+			migrated = true
+			continue
+		}
 
 		record := AnswerSubmissionDetail{
 			TxID:      resp.TxId,
@@ -96,9 +573,65 @@ func (c *AnswerContract) GetAnswerRevisionHistory(
 		}
 
 		submissionRecord = append(submissionRecord, record)
+		nanos = append(nanos, resp.Timestamp.AsTime().UnixNano())
+	}
+
+	order := make([]int, len(submissionRecord))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return nanos[order[i]] < nanos[order[j]] })
+	sorted := make([]AnswerSubmissionDetail, 0, len(order))
+	for _, i := range order {
+		sorted = append(sorted, submissionRecord[i])
+	}
+	return sorted, migrated, nil
+}
+
+// answerKey builds the composite key of an answer after validating each part
+func answerKey(ctx contractapi.TransactionContextInterface, examID, questionID, studentID string) (string, error) {
+	for _, part := range []struct{ name, value string }{
+		{"exam id", examID},
+		{"question id", questionID},
+		{"student id", studentID},
+	} {
+		if err := validateKeyPart(part.name, part.value); err != nil {
+			return "", err
+		}
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(answerObjectType, []string{examID, questionID, studentID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create answer key: %v", err)
+	}
+	return key, nil
+}
+
+// validateKeyPart rejects empty ids and characters Fabric reserves for composite keys
+func validateKeyPart(name, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("%s cannot be empty", name)
+	}
+	if !utf8.ValidString(value) {
+		return fmt.Errorf("%s %q is not valid utf-8", name, value)
 	}
+	if strings.ContainsRune(value, 0) || strings.ContainsRune(value, utf8.MaxRune) {
+		return fmt.Errorf("%s %q contains a reserved character", name, value)
+	}
+	return nil
+}
+
+func legacyAnswerKey(examID, questionID, studentID string) string {
+	return strings.Join([]string{answerObjectType, examID, questionID, studentID}, legacyKeySeparator)
+}
+
+func legacyAnswerPrefix(examID string) string {
+	return answerObjectType + legacyKeySeparator + examID + legacyKeySeparator
+}
 
-	return submissionRecord, nil
+// legacyAnswerRangeEnd is the exclusive end of a range scan over legacy keys of an exam . Every key with the
+// legacy prefix sorts before the prefix with its last separator bumped by one
+func legacyAnswerRangeEnd(examID string) string {
+	return answerObjectType + legacyKeySeparator + examID + string(rune(legacyKeySeparator[0]+1))
 }
 
 func main() {
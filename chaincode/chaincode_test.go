@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+)
+
+// the chaincode is a main package , so its tests live next to it instead of under a tests directory
+
+// openExam schedules examID with the answer window [start , end) and opens it
+func openExam(t *testing.T, stub *fakeStub, examID string, start, end int64) {
+	t.Helper()
+	c := new(AnswerContract)
+	assert.Nil(t, c.CreateExam(stub.tx(t, start-100), examID, start, end))
+	assert.Nil(t, c.OpenExam(stub.tx(t, start-50), examID))
+}
+
+// putLegacyAnswer writes an answer the way the contract did before composite keys
+func putLegacyAnswer(t *testing.T, stub *fakeStub, at int64, examID, questionID, studentID, ans string) {
+	t.Helper()
+	bytes, err := json.Marshal(Answer{AnsString: ans})
+	assert.Nil(t, err)
+	stub.tx(t, at)
+	assert.Nil(t, stub.PutState(legacyAnswerKey(examID, questionID, studentID), bytes))
+}
+
+// readAllPages walks GetExamAnswerHistory until the bookmark comes back empty
+func readAllPages(t *testing.T, stub *fakeStub, examID string, pageSize int32) []ExamAnswerHistory {
+	t.Helper()
+	c := new(AnswerContract)
+	var records []ExamAnswerHistory
+	bookmark := ""
+	for calls := 0; calls < 100; calls++ {
+		page, err := c.GetExamAnswerHistory(stub.tx(t, 9000), examID, pageSize, bookmark)
+		assert.Nil(t, err)
+		records = append(records, page.Records...)
+		if page.Bookmark == "" {
+			return records
+		}
+		bookmark = page.Bookmark
+	}
+	t.Fatal("history pages never ended")
+	return nil
+}
+
+func TestGetExamAnswerHistory_MixedKeys(t *testing.T) {
+	stub := newFakeStub()
+	putLegacyAnswer(t, stub, 900, "exam1", "q1", "s2", "B")
+	putLegacyAnswer(t, stub, 950, "exam1", "q1", "s2", "C")
+	putLegacyAnswer(t, stub, 960, "exam1", "q2", "s3", "D")
+	openExam(t, stub, "exam1", 1000, 5000)
+	c := new(AnswerContract)
+	assert.Nil(t, c.SetAnswer(stub.tx(t, 1100), "exam1", "q1", "s1", "A"))
+	// another exam sharing the legacy prefix up to its id must stay out
+	putLegacyAnswer(t, stub, 970, "exam10", "q1", "s9", "A")
+
+	for _, pageSize := range []int32{1, 2, 10} {
+		records := readAllPages(t, stub, "exam1", pageSize)
+		assert.Len(t, records, 3, "page size %d", pageSize)
+		byStudent := make(map[string]ExamAnswerHistory)
+		for _, r := range records {
+			byStudent[r.StudentID] = r
+		}
+		assert.Equal(t, "q1", byStudent["s1"].QuestionID)
+		assert.Len(t, byStudent["s1"].Revisions, 1)
+		assert.Equal(t, "q1", byStudent["s2"].QuestionID)
+		if assert.Len(t, byStudent["s2"].Revisions, 2) {
+			assert.Equal(t, "B", byStudent["s2"].Revisions[0].Value)
+			assert.Equal(t, "C", byStudent["s2"].Revisions[1].Value)
+		}
+		assert.Equal(t, "q2", byStudent["s3"].QuestionID)
+	}
+}
+
+func TestSetAnswer_AnswerWindow(t *testing.T) {
+	stub := newFakeStub()
+	openExam(t, stub, "exam1", 1000, 2000)
+	c := new(AnswerContract)
+
+	for _, tc := range []struct {
+		at      int64
+		allowed bool
+	}{
+		{999, false},
+		{1000, true},
+		{1500, true},
+		{1999, true},
+		{2000, false},
+		{2600, false},
+	} {
+		err := c.SetAnswer(stub.tx(t, tc.at), "exam1", "q1", "s1", "A")
+		if tc.allowed {
+			assert.Nil(t, err, "tx time %d", tc.at)
+			continue
+		}
+		if assert.NotNil(t, err, "tx time %d", tc.at) {
+			assert.Contains(t, err.Error(), answersRejected)
+		}
+	}
+
+	history, err := c.GetAnswerRevisionHistory(stub.tx(t, 3000), "exam1", "q1", "s1")
+	assert.Nil(t, err)
+	assert.Len(t, history, 3)
+}
+
+func TestSetAnswer_ExamNotOpen(t *testing.T) {
+	stub := newFakeStub()
+	c := new(AnswerContract)
+
+	err := c.SetAnswer(stub.tx(t, 1500), "exam1", "q1", "s1", "A")
+	assert.ErrorContains(t, err, "not scheduled")
+
+	assert.Nil(t, c.CreateExam(stub.tx(t, 900), "exam1", 1000, 2000))
+	err = c.SetAnswer(stub.tx(t, 1500), "exam1", "q1", "s1", "A")
+	assert.ErrorContains(t, err, "it is draft")
+
+	assert.Nil(t, c.OpenExam(stub.tx(t, 950), "exam1"))
+	assert.Nil(t, c.SetAnswer(stub.tx(t, 1500), "exam1", "q1", "s1", "A"))
+
+	// closing early shuts the window even though its end is still ahead
+	assert.Nil(t, c.CloseExam(stub.tx(t, 1600), "exam1"))
+	err = c.SetAnswer(stub.tx(t, 1700), "exam1", "q1", "s1", "B")
+	assert.ErrorContains(t, err, "it is closed")
+
+	assert.Nil(t, c.MarkExamAudited(stub.tx(t, 2500), "exam1"))
+	err = c.SetAnswer(stub.tx(t, 1800), "exam1", "q1", "s1", "B")
+	assert.ErrorContains(t, err, "it is audited")
+
+	exam, err := c.GetExam(stub.tx(t, 2600), "exam1")
+	assert.Nil(t, err)
+	assert.Equal(t, &Exam{ExamID: "exam1", StartTime: 1000, EndTime: 2000, State: ExamAudited}, exam)
+}
+
+func TestExamTransitions(t *testing.T) {
+	stub := newFakeStub()
+	c := new(AnswerContract)
+
+	assert.NotNil(t, c.CreateExam(stub.tx(t, 900), "exam1", 2000, 2000))
+	assert.NotNil(t, c.OpenExam(stub.tx(t, 900), "exam1"))
+	assert.Nil(t, c.CreateExam(stub.tx(t, 900), "exam1", 1000, 2000))
+	assert.NotNil(t, c.CreateExam(stub.tx(t, 900), "exam1", 1000, 3000))
+
+	moves := map[string]func(*AnswerContract, contractapi.TransactionContextInterface, string) error{
+		ExamOpen:    (*AnswerContract).OpenExam,
+		ExamClosed:  (*AnswerContract).CloseExam,
+		ExamAudited: (*AnswerContract).MarkExamAudited,
+	}
+	// from every state , only the next one is reachable
+	for _, state := range []string{ExamDraft, ExamOpen, ExamClosed, ExamAudited} {
+		for to, move := range moves {
+			if to == examTransitions[state] {
+				continue
+			}
+			err := move(c, stub.tx(t, 950), "exam1")
+			assert.ErrorContains(t, err, "can't move from "+state+" to "+to)
+		}
+		if next, ok := examTransitions[state]; ok {
+			assert.Nil(t, moves[next](c, stub.tx(t, 950), "exam1"))
+		}
+	}
+}
+
+func TestExamManagement_RequiresInstructor(t *testing.T) {
+	stub := newFakeStub()
+	c := new(AnswerContract)
+	assert.Nil(t, c.CreateExam(stub.tx(t, 900), "exam1", 1000, 2000))
+
+	// a student may answer but never schedule , move or migrate an exam
+	stub.role = "student"
+	assert.ErrorContains(t, c.CreateExam(stub.tx(t, 900), "exam2", 1000, 2000), "may not manage exams")
+	assert.ErrorContains(t, c.OpenExam(stub.tx(t, 900), "exam1"), "may not manage exams")
+	assert.ErrorContains(t, c.CloseExam(stub.tx(t, 900), "exam1"), "may not manage exams")
+	assert.ErrorContains(t, c.MarkExamAudited(stub.tx(t, 900), "exam1"), "may not manage exams")
+	_, err := c.MigrateLegacyAnswers(stub.tx(t, 900), "exam1", 10)
+	assert.ErrorContains(t, err, "may not manage exams")
+
+	stub.role = roleAdmin
+	assert.Nil(t, c.OpenExam(stub.tx(t, 900), "exam1"))
+	exam, err := c.GetExam(stub.tx(t, 900), "exam1")
+	assert.Nil(t, err)
+	assert.Equal(t, ExamOpen, exam.State)
+}
+
+func TestMigrateLegacyAnswers_MergeOrder(t *testing.T) {
+	stub := newFakeStub()
+	putLegacyAnswer(t, stub, 900, "exam1", "q1", "s1", "A")
+	putLegacyAnswer(t, stub, 950, "exam1", "q1", "s1", "B")
+	putLegacyAnswer(t, stub, 960, "exam1", "q2", "s1", "D")
+	openExam(t, stub, "exam1", 1000, 5000)
+	c := new(AnswerContract)
+
+	// the new contract went live before the migration , q1 was already answered again under the composite key
+	assert.Nil(t, c.SetAnswer(stub.tx(t, 1100), "exam1", "q1", "s1", "C"))
+
+	migrated, err := c.MigrateLegacyAnswers(stub.tx(t, 1200), "exam1", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, migrated)
+	migrated, err = c.MigrateLegacyAnswers(stub.tx(t, 1210), "exam1", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, migrated)
+	migrated, err = c.MigrateLegacyAnswers(stub.tx(t, 1220), "exam1", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, migrated)
+	assert.Nil(t, stub.state[legacyAnswerKey("exam1", "q1", "s1")])
+
+	assert.Nil(t, c.SetAnswer(stub.tx(t, 1300), "exam1", "q1", "s1", "E"))
+
+	// legacy revisions first , no trace of the migration write or the legacy delete
+	history, err := c.GetAnswerRevisionHistory(stub.tx(t, 2000), "exam1", "q1", "s1")
+	assert.Nil(t, err)
+	var values []string
+	var times []int64
+	for _, r := range history {
+		values = append(values, r.Value)
+		times = append(times, r.Timestamp)
+	}
+	assert.Equal(t, []string{"A", "B", "C", "E"}, values)
+	assert.Equal(t, []int64{900, 950, 1100, 1300}, times)
+
+	records := readAllPages(t, stub, "exam1", 10)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, history, records[0].Revisions)
+		assert.Len(t, records[1].Revisions, 1)
+		assert.Equal(t, "D", records[1].Revisions[0].Value)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	stub := newFakeStub()
+	openExam(t, stub, "exam1", 1000, 2000)
+	c := new(AnswerContract)
+	assert.Nil(t, c.SetAnswer(stub.tx(t, 1234), "exam1", "q1", "s1", "A"))
+	// a rejected answer announces nothing
+	assert.NotNil(t, c.SetAnswer(stub.tx(t, 2500), "exam1", "q1", "s1", "B"))
+	assert.Nil(t, c.CloseExam(stub.tx(t, 2600), "exam1"))
+
+	var names []string
+	for _, event := range stub.events {
+		names = append(names, event.EventName)
+	}
+	assert.Equal(t, []string{examStateChangedEvent, answerSubmittedEvent, examStateChangedEvent}, names)
+
+	var answer AnswerEvent
+	assert.Nil(t, json.Unmarshal(stub.events[1].Payload, &answer))
+	assert.Equal(t, AnswerEvent{ExamID: "exam1", Answer: AnswerEventAnswer{QuestionID: "q1", StudentID: "s1", Ans: "A", SubmittedAt: 1234}}, answer)
+
+	// the revision history carries the same time as the event
+	history, err := c.GetAnswerRevisionHistory(stub.tx(t, 3000), "exam1", "q1", "s1")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1234), history[0].Timestamp)
+
+	var state ExamStateEvent
+	assert.Nil(t, json.Unmarshal(stub.events[2].Payload, &state))
+	assert.Equal(t, ExamStateEvent{ExamID: "exam1", State: ExamClosed}, state)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeStub is an in-memory ledger implementing the parts of the stub the contract uses . Every write is recorded
+// in the key history with the current transaction time , and history is returned newest first like Fabric does
+type fakeStub struct {
+	shim.ChaincodeStubInterface
+
+	state   map[string][]byte
+	history map[string][]*queryresult.KeyModification
+	events  []*peer.ChaincodeEvent
+	txTime  time.Time
+	txID    string
+	txCount int
+	// role is the role attribute of the caller of every following transaction
+	role string
+}
+
+func newFakeStub() *fakeStub {
+	return &fakeStub{
+		state:   make(map[string][]byte),
+		history: make(map[string][]*queryresult.KeyModification),
+		txTime:  time.Unix(1000, 0),
+		role:    roleInstructor,
+	}
+}
+
+// tx starts a new transaction at the given unix time and returns a context for it
+func (s *fakeStub) tx(t *testing.T, at int64) *contractapi.TransactionContext {
+	t.Helper()
+	s.txTime = time.Unix(at, 0)
+	s.txCount++
+	s.txID = fmt.Sprintf("tx%d", s.txCount)
+	ctx := new(contractapi.TransactionContext)
+	ctx.SetStub(s)
+	ctx.SetClientIdentity(fakeIdentity{role: s.role})
+	return ctx
+}
+
+// fakeIdentity is a caller whose certificate only carries the role attribute
+type fakeIdentity struct {
+	cid.ClientIdentity
+	role string
+}
+
+func (id fakeIdentity) AssertAttributeValue(attrName, attrValue string) error {
+	if attrName != roleAttribute || id.role != attrValue {
+		return fmt.Errorf("attribute %s is not %s", attrName, attrValue)
+	}
+	return nil
+}
+
+func (s *fakeStub) GetTxID() string { return s.txID }
+
+func (s *fakeStub) GetTxTimestamp() (*timestamppb.Timestamp, error) {
+	return timestamppb.New(s.txTime), nil
+}
+
+func (s *fakeStub) GetState(key string) ([]byte, error) {
+	return s.state[key], nil
+}
+
+func (s *fakeStub) PutState(key string, value []byte) error {
+	s.state[key] = value
+	s.history[key] = append(s.history[key], &queryresult.KeyModification{TxId: s.txID, Value: value, Timestamp: timestamppb.New(s.txTime)})
+	return nil
+}
+
+func (s *fakeStub) DelState(key string) error {
+	delete(s.state, key)
+	s.history[key] = append(s.history[key], &queryresult.KeyModification{TxId: s.txID, Timestamp: timestamppb.New(s.txTime), IsDelete: true})
+	return nil
+}
+
+func (s *fakeStub) SetEvent(name string, payload []byte) error {
+	s.events = append(s.events, &peer.ChaincodeEvent{EventName: name, Payload: payload, TxId: s.txID})
+	return nil
+}
+
+func (s *fakeStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return shim.CreateCompositeKey(objectType, attributes)
+}
+
+func (s *fakeStub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(compositeKey, "\x00"), "\x00"), "\x00")
+	return parts[0], parts[1:], nil
+}
+
+func (s *fakeStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	var out []*queryresult.KeyModification
+	for i := len(s.history[key]) - 1; i >= 0; i-- {
+		out = append(out, s.history[key][i])
+	}
+	return &fakeHistoryIterator{items: out}, nil
+}
+
+func (s *fakeStub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	return &fakeStateIterator{items: s.scan(startKey, endKey, "", 0)}, nil
+}
+
+func (s *fakeStub) GetStateByPartialCompositeKey(objectType string, attributes []string) (shim.StateQueryIteratorInterface, error) {
+	prefix, err := shim.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetStateByRange(prefix, prefix+string(rune(0x10FFFF)))
+}
+
+func (s *fakeStub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	items := s.scan(startKey, endKey, bookmark, int(pageSize)+1)
+	meta := &peer.QueryResponseMetadata{}
+	if len(items) > int(pageSize) {
+		meta.Bookmark = items[pageSize].Key
+		items = items[:pageSize]
+	}
+	meta.FetchedRecordsCount = int32(len(items))
+	return &fakeStateIterator{items: items}, meta, nil
+}
+
+func (s *fakeStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	prefix, err := shim.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.GetStateByRangeWithPagination(prefix, prefix+string(rune(0x10FFFF)), pageSize, bookmark)
+}
+
+// scan returns up to limit keys in [startKey , endKey) from bookmark on , every key when limit is zero
+func (s *fakeStub) scan(startKey, endKey, bookmark string, limit int) []*queryresult.KV {
+	if bookmark > startKey {
+		startKey = bookmark
+	}
+	var keys []string
+	for key := range s.state {
+		if key >= startKey && key < endKey {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+	items := make([]*queryresult.KV, 0, len(keys))
+	for _, key := range keys {
+		items = append(items, &queryresult.KV{Key: key, Value: s.state[key]})
+	}
+	return items
+}
+
+type fakeStateIterator struct {
+	items []*queryresult.KV
+}
+
+func (it *fakeStateIterator) HasNext() bool { return len(it.items) > 0 }
+func (it *fakeStateIterator) Close() error  { return nil }
+func (it *fakeStateIterator) Next() (*queryresult.KV, error) {
+	item := it.items[0]
+	it.items = it.items[1:]
+	return item, nil
+}
+
+type fakeHistoryIterator struct {
+	items []*queryresult.KeyModification
+}
+
+func (it *fakeHistoryIterator) HasNext() bool { return len(it.items) > 0 }
+func (it *fakeHistoryIterator) Close() error  { return nil }
+func (it *fakeHistoryIterator) Next() (*queryresult.KeyModification, error) {
+	item := it.items[0]
+	it.items = it.items[1:]
+	return item, nil
+}
@@ -0,0 +1,217 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+	"github.com/deeraj-kumar/exam-audit/scoring"
+)
+
+const (
+	AlgorithmComponents       = "components"
+	AlgorithmLabelPropagation = "label_propagation"
+
+	defaultMinSize      = 3
+	maxPropagationRound = 100
+)
+
+type graph map[string]map[string]float64
+
+// ValidateAlgorithm rejects clustering algorithms DetectGroups doesn't know , an empty one means label propagation
+func ValidateAlgorithm(algorithm string) error {
+	switch algorithm {
+	case "", AlgorithmComponents, AlgorithmLabelPropagation:
+		return nil
+	}
+	return fmt.Errorf("unknown clustering algorithm %s , supported algorithms - %v", algorithm, []string{AlgorithmComponents, AlgorithmLabelPropagation})
+}
+
+// Validate rejects clustering settings DetectGroups can't honour . Edge weights must lie in [0,1] , which
+// flagged_count pair scores don't
+func Validate(cfg model.ClusteringConfig, scoringCfg model.ScoringConfig) error {
+	if err := ValidateAlgorithm(cfg.Algorithm); err != nil {
+		return err
+	}
+	if !scoringCfg.Significance.Enabled && scoringCfg.Aggregation.Combiner == scoring.CombinerFlaggedCount {
+		return fmt.Errorf("clustering needs pair scores within [0,1] , the %s combiner counts questions", scoring.CombinerFlaggedCount)
+	}
+	return nil
+}
+
+// DetectGroups finds groups of students linked by suspicious pairs . Edges at or below the edge threshold
+// are dropped , the remaining graph is split into connected components and , unless only components are
+// requested , each component is refined with weighted label propagation . With significance testing every
+// reported pair already survived the false discovery rate , so all of them are edges weighted by 1 - q-value
+func DetectGroups(adj model.AdjacencyList, cfg model.ClusteringConfig, scoringCfg model.ScoringConfig, fallbackThreshold float64) ([]model.CollusionGroup, error) {
+	if err := Validate(cfg, scoringCfg); err != nil {
+		return nil, err
+	}
+	threshold := cfg.EdgeThreshold
+	if threshold <= 0 {
+		threshold = fallbackThreshold
+	}
+	minSize := cfg.MinSize
+	if minSize <= 0 {
+		minSize = defaultMinSize
+	}
+
+	g := make(graph)
+	for _, item := range adj {
+		if item.StudentA == item.StudentB {
+			continue
+		}
+		if scoringCfg.Significance.Enabled {
+			g.addEdge(item.StudentA, item.StudentB, 1-item.QValue)
+			continue
+		}
+		if item.Score > threshold {
+			g.addEdge(item.StudentA, item.StudentB, item.Score)
+		}
+	}
+
+	var groups []model.CollusionGroup
+	for _, component := range g.components() {
+		communities := [][]string{component}
+		if cfg.Algorithm != AlgorithmComponents {
+			communities = g.labelPropagation(component)
+		}
+		for _, members := range communities {
+			if len(members) < minSize {
+				continue
+			}
+			groups = append(groups, g.group(members))
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Cohesion != groups[j].Cohesion {
+			return groups[i].Cohesion > groups[j].Cohesion
+		}
+		return groups[i].Students[0] < groups[j].Students[0]
+	})
+	return groups, nil
+}
+
+func (g graph) addEdge(a, b string, w float64) {
+	if _, ok := g[a]; !ok {
+		g[a] = make(map[string]float64)
+	}
+	if _, ok := g[b]; !ok {
+		g[b] = make(map[string]float64)
+	}
+	// keep the strongest evidence if a pair shows up more than once
+	g[a][b] = max(g[a][b], w)
+	g[b][a] = max(g[b][a], w)
+}
+
+func (g graph) nodes() []string {
+	nodes := make([]string, 0, len(g))
+	for n := range g {
+		nodes = append(nodes, n)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+func (g graph) neighbours(n string) []string {
+	out := make([]string, 0, len(g[n]))
+	for m := range g[n] {
+		out = append(out, m)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// components returns the connected components , each sorted , in order of their smallest member
+func (g graph) components() [][]string {
+	seen := make(map[string]bool, len(g))
+	var out [][]string
+	for _, start := range g.nodes() {
+		if seen[start] {
+			continue
+		}
+		seen[start] = true
+		component := []string{start}
+		queue := []string{start}
+		for len(queue) > 0 {
+			n := queue[0]
+			queue = queue[1:]
+			for _, m := range g.neighbours(n) {
+				if !seen[m] {
+					seen[m] = true
+					component = append(component, m)
+					queue = append(queue, m)
+				}
+			}
+		}
+		sort.Strings(component)
+		out = append(out, component)
+	}
+	return out
+}
+
+// labelPropagation splits a component into communities . Every node repeatedly adopts the label with the
+// highest total edge weight among its neighbours , ties going to the smallest label so runs are repeatable
+func (g graph) labelPropagation(component []string) [][]string {
+	labels := make(map[string]string, len(component))
+	for _, n := range component {
+		labels[n] = n
+	}
+
+	for round := 0; round < maxPropagationRound; round++ {
+		changed := false
+		for _, n := range component {
+			weights := make(map[string]float64)
+			for m, w := range g[n] {
+				weights[labels[m]] += w
+			}
+			best := labels[n]
+			bestWeight := weights[best]
+			for label, w := range weights {
+				if w > bestWeight || (w == bestWeight && label < best) {
+					best, bestWeight = label, w
+				}
+			}
+			if best != labels[n] {
+				labels[n] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	byLabel := make(map[string][]string)
+	for _, n := range component {
+		byLabel[labels[n]] = append(byLabel[labels[n]], n)
+	}
+	out := make([][]string, 0, len(byLabel))
+	for _, members := range byLabel {
+		sort.Strings(members)
+		out = append(out, members)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i][0] < out[j][0] })
+	return out
+}
+
+// group computes the cohesion of members as the total internal edge weight over the number of possible pairs
+func (g graph) group(members []string) model.CollusionGroup {
+	var sum float64
+	var edges int
+	for i := 0; i < len(members); i++ {
+		for j := i + 1; j < len(members); j++ {
+			if w, ok := g[members[i]][members[j]]; ok {
+				sum += w
+				edges++
+			}
+		}
+	}
+	possible := len(members) * (len(members) - 1) / 2
+	return model.CollusionGroup{
+		Students: members,
+		Cohesion: sum / float64(possible),
+		Edges:    edges,
+	}
+}
@@ -0,0 +1,83 @@
+package cluster_test
+
+import (
+	"testing"
+
+	"github.com/deeraj-kumar/exam-audit/cluster"
+	model "github.com/deeraj-kumar/exam-audit/domain"
+	"github.com/deeraj-kumar/exam-audit/scoring"
+	"github.com/stretchr/testify/assert"
+)
+
+// two tight rings of three joined by a single bridge between s3 and s4
+var twoRings = model.AdjacencyList{
+	{StudentA: "s1", StudentB: "s2", Score: 0.95},
+	{StudentA: "s2", StudentB: "s3", Score: 0.9},
+	{StudentA: "s1", StudentB: "s3", Score: 0.92},
+	{StudentA: "s4", StudentB: "s5", Score: 0.91},
+	{StudentA: "s5", StudentB: "s6", Score: 0.93},
+	{StudentA: "s4", StudentB: "s6", Score: 0.9},
+	{StudentA: "s3", StudentB: "s4", Score: 0.75},
+	{StudentA: "s8", StudentB: "s9", Score: 0.99},
+}
+
+func TestDetectGroups_LabelPropagation(t *testing.T) {
+	groups, err := cluster.DetectGroups(twoRings, model.ClusteringConfig{Algorithm: cluster.AlgorithmLabelPropagation}, model.ScoringConfig{}, 0.7)
+	assert.Nil(t, err)
+
+	assert.Len(t, groups, 2)
+	assert.Equal(t, []string{"s1", "s2", "s3"}, groups[0].Students)
+	assert.Equal(t, []string{"s4", "s5", "s6"}, groups[1].Students)
+	assert.Equal(t, 3, groups[0].Edges)
+	assert.InDelta(t, (0.95+0.9+0.92)/3, groups[0].Cohesion, 1e-9)
+}
+
+func TestDetectGroups_Components(t *testing.T) {
+	groups, err := cluster.DetectGroups(twoRings, model.ClusteringConfig{Algorithm: cluster.AlgorithmComponents}, model.ScoringConfig{}, 0.7)
+	assert.Nil(t, err)
+
+	assert.Len(t, groups, 1)
+	assert.Equal(t, []string{"s1", "s2", "s3", "s4", "s5", "s6"}, groups[0].Students)
+	assert.Equal(t, 7, groups[0].Edges)
+}
+
+func TestDetectGroups_UnknownAlgorithm(t *testing.T) {
+	groups, err := cluster.DetectGroups(twoRings, model.ClusteringConfig{Algorithm: "louvain"}, model.ScoringConfig{}, 0.7)
+
+	assert.NotNil(t, err)
+	assert.Empty(t, groups)
+	assert.NotNil(t, cluster.ValidateAlgorithm("louvain"))
+	assert.Nil(t, cluster.ValidateAlgorithm(""))
+	assert.Nil(t, cluster.ValidateAlgorithm(cluster.AlgorithmComponents))
+}
+
+func TestDetectGroups_EdgeThreshold(t *testing.T) {
+	groups, err := cluster.DetectGroups(twoRings, model.ClusteringConfig{Algorithm: cluster.AlgorithmComponents, EdgeThreshold: 0.8}, model.ScoringConfig{}, 0.7)
+	assert.Nil(t, err)
+
+	assert.Len(t, groups, 2)
+}
+
+func TestDetectGroups_FlaggedCountRejected(t *testing.T) {
+	counts := model.ScoringConfig{Aggregation: model.AggregationConfig{Combiner: scoring.CombinerFlaggedCount}}
+
+	_, err := cluster.DetectGroups(twoRings, model.ClusteringConfig{}, counts, 0.7)
+	assert.NotNil(t, err)
+	assert.NotNil(t, cluster.Validate(model.ClusteringConfig{}, counts))
+}
+
+func TestDetectGroups_SignificanceWeightsByQValue(t *testing.T) {
+	significance := model.ScoringConfig{Significance: model.SignificanceConfig{Enabled: true}}
+	// significant pairs can score below the fixed threshold , they are edges all the same
+	adj := model.AdjacencyList{
+		{StudentA: "s1", StudentB: "s2", Score: 0.4, QValue: 0.01},
+		{StudentA: "s2", StudentB: "s3", Score: 0.3, QValue: 0.03},
+		{StudentA: "s1", StudentB: "s3", Score: 0.5, QValue: 0.02},
+	}
+
+	groups, err := cluster.DetectGroups(adj, model.ClusteringConfig{Algorithm: cluster.AlgorithmComponents}, significance, 0.7)
+	assert.Nil(t, err)
+	assert.Len(t, groups, 1)
+	assert.Equal(t, []string{"s1", "s2", "s3"}, groups[0].Students)
+	assert.InDelta(t, 0.98, groups[0].Cohesion, 1e-9)
+}
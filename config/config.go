@@ -4,7 +4,9 @@ import (
 	"log"
 	"os"
 
+	"github.com/deeraj-kumar/exam-audit/cluster"
 	model "github.com/deeraj-kumar/exam-audit/domain"
+	"github.com/deeraj-kumar/exam-audit/scoring"
 	"github.com/spf13/viper"
 )
 
@@ -26,5 +28,28 @@ func LoadConfig() error {
 
 	viper.Unmarshal(&Cfg)
 	Cfg.WorkingDir = workingDir
+	if err := validate(Cfg); err != nil {
+		log.Printf("invalid config.yaml , err - %v", err)
+		return err
+	}
+	return nil
+}
+
+// validate rejects settings that would make an audit run but silently report nothing or run something other
+// than what was configured
+func validate(cfg model.Config) error {
+	if cfg.Scoring.Significance.Enabled {
+		if err := scoring.ValidateSignificance(cfg.Scoring.Significance); err != nil {
+			return err
+		}
+		if err := scoring.ValidateSignificanceCombiner(cfg.Scoring.Aggregation); err != nil {
+			return err
+		}
+	}
+	if cfg.Clustering.Enabled {
+		if err := cluster.Validate(cfg.Clustering, cfg.Scoring); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -12,8 +12,86 @@ type Config struct {
 			KeyPath  string `mapstructure:"keypath"`
 		} `mapstructure:"fabric_identity"`
 	} `mapstructure:"fabric_params"`
-	SuspicionScoreThreshold float64 `mapstructure:"suspicion_score_threshold"`
-	WorkingDir              string  `mapstructure:"working_dir"`
+	SuspicionScoreThreshold float64          `mapstructure:"suspicion_score_threshold"`
+	WorkingDir              string           `mapstructure:"working_dir"`
+	Scoring                 ScoringConfig    `mapstructure:"scoring"`
+	Clustering              ClusteringConfig `mapstructure:"clustering"`
+	Anomaly                 AnomalyConfig    `mapstructure:"anomaly"`
+}
+
+type AnomalyConfig struct {
+	Enabled                  bool    `mapstructure:"enabled"`
+	FinalWindowSeconds       int64   `mapstructure:"final_window_seconds"`
+	FinalBurstShare          float64 `mapstructure:"final_burst_share"`
+	MinBurstAnswers          int     `mapstructure:"min_burst_answers"`
+	ConvergenceWindowSeconds int64   `mapstructure:"convergence_window_seconds"`
+	MinCorrections           int     `mapstructure:"min_corrections"`
+	VelocityZScore           float64 `mapstructure:"velocity_z_score"`
+}
+
+type ClusteringConfig struct {
+	Enabled       bool    `mapstructure:"enabled"`
+	Algorithm     string  `mapstructure:"algorithm"`
+	EdgeThreshold float64 `mapstructure:"edge_threshold"`
+	MinSize       int     `mapstructure:"min_size"`
+}
+
+type ScoringConfig struct {
+	Scorers      []ScorerConfig     `mapstructure:"scorers"`
+	Aggregation  AggregationConfig  `mapstructure:"aggregation"`
+	Significance SignificanceConfig `mapstructure:"significance"`
+	Lag          LagConfig          `mapstructure:"lag"`
+	// Workers bounds the number of goroutines scoring pairs and the null distribution , defaulting to GOMAXPROCS
+	Workers int           `mapstructure:"workers"`
+	Pruning PruningConfig `mapstructure:"pruning"`
+}
+
+// PruningConfig controls the locality-sensitive hashing pass that picks candidate pairs before scoring .
+// Recall is the probability of keeping a pair whose sets of wrong or rare answers have a Jaccard similarity of
+// at least SimilarityThreshold . An answer is rare when at most RareAnswerShare of the students answering the
+// question ever held it , and hash buckets of more than MaxBucketSize students are skipped
+type PruningConfig struct {
+	Enabled             bool    `mapstructure:"enabled"`
+	MinStudents         int     `mapstructure:"min_students"`
+	Recall              float64 `mapstructure:"recall"`
+	SimilarityThreshold float64 `mapstructure:"similarity_threshold"`
+	NumHashes           int     `mapstructure:"num_hashes"`
+	RareAnswerShare     float64 `mapstructure:"rare_answer_share"`
+	MaxBucketSize       int     `mapstructure:"max_bucket_size"`
+	Seed                uint64  `mapstructure:"seed"`
+}
+
+// LagConfig is the one place the lead-lag window is configured , for the time_correlation scorer and the lag
+// reported on flagged pairs alike . ToleranceSeconds is a pointer so an explicit zero , exact delays only , is
+// told apart from unset . MinSamples is the number of revisions that have to follow at the same lag for the
+// lag to count as consistent
+type LagConfig struct {
+	MaxLagSeconds    int64  `mapstructure:"max_lag_seconds"`
+	ToleranceSeconds *int64 `mapstructure:"tolerance_seconds"`
+	MinSamples       int    `mapstructure:"min_samples"`
+}
+
+// SignificanceConfig controls the permutation test of pair scores . Permutations is a floor , the audit draws as
+// many as it takes for the smallest p-value to survive the false discovery rate over every pair , up to
+// MaxPermutations when set
+type SignificanceConfig struct {
+	Enabled         bool    `mapstructure:"enabled"`
+	Permutations    int     `mapstructure:"permutations"`
+	MaxPermutations int     `mapstructure:"max_permutations"`
+	FDR             float64 `mapstructure:"fdr"`
+	Seed            int64   `mapstructure:"seed"`
+}
+
+type AggregationConfig struct {
+	Combiner string `mapstructure:"combiner"`
+	TopK     int    `mapstructure:"top_k"`
+}
+
+type ScorerConfig struct {
+	Name    string             `mapstructure:"name"`
+	Weight  float64            `mapstructure:"weight"`
+	Enabled bool               `mapstructure:"enabled"`
+	Params  map[string]float64 `mapstructure:"params"`
 }
 
 type Exams struct {
@@ -24,9 +102,36 @@ type Exam struct {
 	Questions []Question `json:"questions"`
 }
 
+const (
+	QuestionTypeMCQ         = "mcq"
+	QuestionTypeMultiSelect = "multi_select"
+	QuestionTypeNumeric     = "numeric"
+	QuestionTypeFreeText    = "free_text"
+	QuestionTypeCode        = "code"
+
+	// MultiSelectSeparator separates the chosen options of a multi select answer
+	MultiSelectSeparator = ","
+)
+
 type Question struct {
-	QuestionID string `json:"questionID"`
-	Question   string `json:"question"`
+	QuestionID    string           `json:"questionID"`
+	Question      string           `json:"question"`
+	Type          string           `json:"type,omitempty"`
+	Options       []string         `json:"options,omitempty"`
+	Metadata      QuestionMetadata `json:"metadata"`
+	CorrectAnswer string           `json:"correctAnswer,omitempty"`
+}
+
+// QuestionMetadata holds the settings that only apply to some question types
+type QuestionMetadata struct {
+	// MaxSelections caps the number of options of a multi select answer
+	MaxSelections int `json:"maxSelections,omitempty"`
+	// Tolerance is the absolute difference under which two numeric answers are equal
+	Tolerance float64 `json:"tolerance,omitempty"`
+	// MaxLength caps the number of characters of a free text or code answer
+	MaxLength int `json:"maxLength,omitempty"`
+	// Language of a code answer
+	Language string `json:"language,omitempty"`
 }
 
 type Students struct {
@@ -43,6 +148,25 @@ type Answer struct {
 	Ans         string `json:"ans"`
 	StudentID   string `json:"studentID"`
 	SubmittedAt int64  `json:"submittedAt"`
+	// TxID is the ledger transaction that wrote the revision , when known
+	TxID string `json:"txId,omitempty"`
+}
+
+// Chaincode event names , mirrored from the chaincode
+const (
+	LedgerEventAnswerSubmitted  = "AnswerSubmitted"
+	LedgerEventExamStateChanged = "ExamStateChanged"
+)
+
+// LedgerEvent is a chaincode event about an exam , a committed answer or a lifecycle move
+type LedgerEvent struct {
+	Name   string `json:"-"`
+	TxID   string `json:"-"`
+	ExamID string `json:"examID"`
+	// Answer is set on AnswerSubmitted , SubmittedAt being the transaction time the ledger records
+	Answer *Answer `json:"answer,omitempty"`
+	// State is set on ExamStateChanged
+	State string `json:"state,omitempty"`
 }
 
 type AnswerRevision struct {
@@ -51,17 +175,142 @@ type AnswerRevision struct {
 }
 
 type AdjacencyItem struct {
-	StudentA string  `json:"studentA"`
-	StudentB string  `json:"studentB"`
-	Score    float64 `json:"score"`
-	//Reason   string  `json:"reason,omitempty"`
+	StudentA         string          `json:"studentA"`
+	StudentB         string          `json:"studentB"`
+	Score            float64         `json:"score"`
+	FlaggedQuestions []string        `json:"flaggedQuestions"`
+	Questions        []QuestionScore `json:"questions"`
+	// ExamScore is the weighted exam-level result , kept apart from Score which only aggregates the questions
+	ExamScore   float64            `json:"examScore,omitempty"`
+	ExamScores  map[string]float64 `json:"examScores,omitempty"`
+	ExamFlagged bool               `json:"examFlagged,omitempty"`
+	Lag         *LeadLag           `json:"lag,omitempty"`
+	Direction   *Direction         `json:"direction,omitempty"`
+	PValue      float64            `json:"pValue,omitempty"`
+	QValue      float64            `json:"qValue,omitempty"`
+	Reason      *Reason            `json:"reason,omitempty"`
+}
+
+// LeadLag is the dominant delay between the edit timelines of a pair and which student moves first
+type LeadLag struct {
+	Leader     string  `json:"leader"`
+	Follower   string  `json:"follower"`
+	LagSeconds int64   `json:"lagSeconds"`
+	Strength   float64 `json:"strength"`
+}
+
+// Direction attributes a flagged pair to the student who shared answers (source) and the one who copied (target)
+type Direction struct {
+	Source      string  `json:"source"`
+	Target      string  `json:"target"`
+	Confidence  float64 `json:"confidence"`
+	FirstCommit int     `json:"firstCommit"`
+	Convergence int     `json:"convergence"`
+}
+
+// Reason explains why a pair was flagged so the decision can be justified to a reviewer
+type Reason struct {
+	Summary  string             `json:"summary"`
+	Evidence []QuestionEvidence `json:"evidence"`
+}
+
+type QuestionEvidence struct {
+	QuestionID        string             `json:"questionID"`
+	Score             float64            `json:"score"`
+	SubScores         map[string]float64 `json:"subScores"`
+	MatchingRevisions []RevisionMatch    `json:"matchingRevisions"`
+}
+
+// RevisionMatch is a revision both students made in the same relative order
+type RevisionMatch struct {
+	Ans          string `json:"ans"`
+	IndexA       int    `json:"indexA"`
+	IndexB       int    `json:"indexB"`
+	SubmittedAtA int64  `json:"submittedAtA"`
+	SubmittedAtB int64  `json:"submittedAtB"`
+	// DeltaSeconds is SubmittedAtB minus SubmittedAtA , negative when B submitted first
+	DeltaSeconds int64 `json:"deltaSeconds"`
+}
+
+type QuestionScore struct {
+	QuestionID string  `json:"questionID"`
+	Score      float64 `json:"score"`
+	Flagged    bool    `json:"flagged"`
 }
 
 type AdjacencyList []AdjacencyItem
 
 type AuditReportResponse struct {
-	ExamID string        `json:"examID" binding:"required"`
-	Report AdjacencyList `json:"report" binding:"required"`
+	ExamID    string           `json:"examID" binding:"required"`
+	Report    AdjacencyList    `json:"report" binding:"required"`
+	Groups    []CollusionGroup `json:"groups,omitempty"`
+	Anomalies []StudentAnomaly `json:"anomalies,omitempty"`
+	Metadata  *ReportMetadata  `json:"metadata,omitempty"`
+	Roster    *RosterCheck     `json:"roster,omitempty"`
+}
+
+// RosterCheck compares the students who submitted answers on the ledger with the exam roster
+type RosterCheck struct {
+	// NotOnRoster lists students who submitted answers but are missing from the roster
+	NotOnRoster []string `json:"notOnRoster"`
+	// NoSubmission lists rostered students without a single answer on the ledger
+	NoSubmission []string `json:"noSubmission"`
+}
+
+// SuspicionEvent is one change of a live audit report pushed to proctors
+type SuspicionEvent struct {
+	Type             string   `json:"type"`
+	StudentA         string   `json:"studentA"`
+	StudentB         string   `json:"studentB"`
+	Score            float64  `json:"score"`
+	PreviousScore    float64  `json:"previousScore,omitempty"`
+	FlaggedQuestions []string `json:"flaggedQuestions,omitempty"`
+	Summary          string   `json:"summary,omitempty"`
+}
+
+const (
+	EventPairFlagged  = "pair_flagged"
+	EventScoreChanged = "score_changed"
+	EventPairCleared  = "pair_cleared"
+)
+
+// TimelineEvent is a report change during a replayed exam , At being the ledger time of the revision that caused it
+type TimelineEvent struct {
+	At            int64 `json:"at"`
+	OffsetSeconds int64 `json:"offsetSeconds"`
+	SuspicionEvent
+}
+
+// ReplayTimeline lists every report change of a replayed exam in the order it happened
+type ReplayTimeline struct {
+	ExamID string          `json:"examID"`
+	Events []TimelineEvent `json:"events"`
+}
+
+// ReportMetadata describes how much of the pair space an audit actually scored
+type ReportMetadata struct {
+	Students    int `json:"students"`
+	TotalPairs  int `json:"totalPairs"`
+	ScoredPairs int `json:"scoredPairs"`
+	PrunedPairs int `json:"prunedPairs"`
+}
+
+// StudentAnomaly is a suspicious behaviour of a single student , independent of any other student
+type StudentAnomaly struct {
+	StudentID string `json:"studentID"`
+	Kind      string `json:"kind"`
+	// Score is a share in [0,1] for final_burst and key_convergence , for answer_velocity it is the unbounded
+	// robust z-score of the student's pace against the cohort , at least the configured velocity_z_score
+	Score     float64  `json:"score"`
+	Questions []string `json:"questions,omitempty"`
+	Detail    string   `json:"detail"`
+}
+
+// CollusionGroup is a set of students connected by suspicious pairs
+type CollusionGroup struct {
+	Students []string `json:"students"`
+	Cohesion float64  `json:"cohesion"`
+	Edges    int      `json:"edges"`
 }
 
 type AnswerHistoryRecord struct {
@@ -75,6 +324,45 @@ type AnswerHistory struct {
 	IsDelete  bool   `json:"isDelete"`
 }
 
+// ExamAnswerHistory is the revision history of one student's answer to one question
+type ExamAnswerHistory struct {
+	QuestionID string          `json:"questionID"`
+	StudentID  string          `json:"studentID"`
+	Revisions  []AnswerHistory `json:"revisions"`
+}
+
+// ExamAnswerHistoryPage is one page of GetExamAnswerHistory , an empty Bookmark means there are no more pages
+type ExamAnswerHistoryPage struct {
+	Records             []ExamAnswerHistory `json:"records"`
+	Bookmark            string              `json:"bookmark"`
+	FetchedRecordsCount int32               `json:"fetchedRecordsCount"`
+}
+
+// Exam lifecycle states mirrored from the chaincode , an exam only ever moves one step forward
+const (
+	ExamStateDraft   = "draft"
+	ExamStateOpen    = "open"
+	ExamStateClosed  = "closed"
+	ExamStateAudited = "audited"
+)
+
+// ExamSchedule is the answer window and lifecycle state of an exam on the ledger , times are unix seconds
+type ExamSchedule struct {
+	ExamID    string `json:"examID"`
+	StartTime int64  `json:"startTime"`
+	EndTime   int64  `json:"endTime"`
+	State     string `json:"state"`
+}
+
+type ScheduleExamRequest struct {
+	StartTime int64 `json:"startTime" binding:"required"`
+	EndTime   int64 `json:"endTime" binding:"required"`
+}
+
+type ExamStateRequest struct {
+	State string `json:"state" binding:"required"`
+}
+
 type SubmitAnswerRequest struct {
 	StudentID  string `json:"studentId" binding:"required"`
 	ExamID     string `json:"examId" binding:"required"`
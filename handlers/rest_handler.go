@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 
 	"github.com/deeraj-kumar/exam-audit/auditengine"
 	model "github.com/deeraj-kumar/exam-audit/domain"
+	"github.com/deeraj-kumar/exam-audit/service"
+	"github.com/deeraj-kumar/exam-audit/util"
 	"github.com/gin-gonic/gin"
 )
 
@@ -25,6 +29,12 @@ func NewHandler(ae auditengine.ExamAuditHandler) Handler {
 func (h *handlerImpl) RegisterRoutes(r *gin.Engine) {
 	r.POST("/submit-answer", h.SubmitAnswer)
 	r.GET("/audit-answer", h.AuditAnswer)
+	r.GET("/exams/:examID/suspicion", h.LiveAudit)
+	r.GET("/exams/:examID/suspicion/stream", h.StreamSuspicion)
+	r.GET("/exams/:examID/replay", h.ReplayExam)
+	r.POST("/exams/:examID/schedule", h.ScheduleExam)
+	r.GET("/exams/:examID/schedule", h.GetExamSchedule)
+	r.POST("/exams/:examID/state", h.SetExamState)
 }
 
 func (h *handlerImpl) SubmitAnswer(c *gin.Context) {
@@ -35,6 +45,14 @@ func (h *handlerImpl) SubmitAnswer(c *gin.Context) {
 	}
 
 	if err := h.auditEngine.SubmitAnswer(req.StudentID, req.ExamID, req.QuestionID, req.Ans); err != nil {
+		if errors.Is(err, util.ErrUnknownQuestion) || errors.Is(err, util.ErrInvalidAnswer) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrAnswerRejected) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -50,10 +68,182 @@ func (h *handlerImpl) AuditAnswer(c *gin.Context) {
 		return
 	}
 
-	auditResp, err := h.auditEngine.AuditAnswer(instructorId, examID)
+	auditResp, err := h.auditEngine.AuditAnswer(c.Request.Context(), instructorId, examID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(auditErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, auditResp)
+}
+
+func (h *handlerImpl) LiveAudit(c *gin.Context) {
+	instructorId := c.Query("instructorId")
+	examID := c.Param("examID")
+
+	if instructorId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "instructorId is required"})
+		return
+	}
+
+	auditResp, err := h.auditEngine.LiveAudit(c.Request.Context(), instructorId, examID)
+	if err != nil {
+		c.JSON(auditErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, auditResp)
 }
+
+// StreamSuspicion sends the live audit of an exam as Server-Sent Events , one event per report change named
+// after its type , until the client goes away
+func (h *handlerImpl) StreamSuspicion(c *gin.Context) {
+	instructorId := c.Query("instructorId")
+	examID := c.Param("examID")
+
+	if instructorId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "instructorId is required"})
+		return
+	}
+
+	events, err := h.auditEngine.StreamSuspicion(c.Request.Context(), instructorId, examID)
+	if err != nil {
+		c.JSON(auditErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Stream(func(w io.Writer) bool {
+		event, ok := <-events
+		if !ok {
+			return false
+		}
+		c.SSEvent(event.Type, event)
+		return true
+	})
+}
+
+// ReplayExam returns the flag timeline of a finished exam . An instant replay answers with the whole timeline
+// at once , a paced replay such as 1x or 10x streams the timeline events as Server-Sent Events
+func (h *handlerImpl) ReplayExam(c *gin.Context) {
+	instructorId := c.Query("instructorId")
+	examID := c.Param("examID")
+
+	if instructorId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "instructorId is required"})
+		return
+	}
+	speed, err := util.ParseReplaySpeed(c.DefaultQuery("speed", "instant"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if speed == 0 {
+		timeline := model.ReplayTimeline{ExamID: examID, Events: []model.TimelineEvent{}}
+		err := h.auditEngine.ReplayExam(c.Request.Context(), instructorId, examID, speed, func(event model.TimelineEvent) {
+			timeline.Events = append(timeline.Events, event)
+		})
+		if err != nil {
+			c.JSON(auditErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, timeline)
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	err = h.auditEngine.ReplayExam(c.Request.Context(), instructorId, examID, speed, func(event model.TimelineEvent) {
+		c.SSEvent(event.Type, event)
+		c.Writer.Flush()
+	})
+	if err != nil {
+		if c.Request.Context().Err() == nil {
+			c.SSEvent("error", gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.SSEvent("replay_finished", gin.H{"examID": examID})
+}
+
+// ScheduleExam creates an exam on the ledger as a draft with the answer window given in unix seconds
+func (h *handlerImpl) ScheduleExam(c *gin.Context) {
+	instructorId := c.Query("instructorId")
+	examID := c.Param("examID")
+
+	if instructorId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "instructorId is required"})
+		return
+	}
+	var req model.ScheduleExamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.EndTime <= req.StartTime {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "endTime must be after startTime"})
+		return
+	}
+
+	if err := h.auditEngine.ScheduleExam(instructorId, examID, req.StartTime, req.EndTime); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusCreated)
+}
+
+func (h *handlerImpl) GetExamSchedule(c *gin.Context) {
+	instructorId := c.Query("instructorId")
+	examID := c.Param("examID")
+
+	if instructorId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "instructorId is required"})
+		return
+	}
+
+	schedule, err := h.auditEngine.GetExamSchedule(instructorId, examID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, schedule)
+}
+
+// SetExamState moves an exam to open , closed or audited . The ledger only allows one step forward at a time
+func (h *handlerImpl) SetExamState(c *gin.Context) {
+	instructorId := c.Query("instructorId")
+	examID := c.Param("examID")
+
+	if instructorId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "instructorId is required"})
+		return
+	}
+	var req model.ExamStateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	switch req.State {
+	case model.ExamStateOpen, model.ExamStateClosed, model.ExamStateAudited:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "state must be one of open , closed or audited"})
+		return
+	}
+
+	if err := h.auditEngine.SetExamState(instructorId, examID, req.State); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// auditErrorStatus maps an audit failure to its HTTP status
+func auditErrorStatus(err error) int {
+	if errors.Is(err, util.ErrUnknownExam) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, auditengine.ErrExamNotLive) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"os"
 	"os/signal"
@@ -22,6 +23,9 @@ func main() {
 	// channelName := getenv("FABRIC_CHANNEL", "mychannel")
 	// chaincodeName := getenv("FABRIC_CHAINCODE", "exam")
 	// susScoreThreshold:=getenv("SUSPICION_SCORE_THRESHOLD","0.7")
+	migrateExam := flag.String("migrate-legacy-keys", "", "move the answers of this exam from the old Answer~ keys to composite keys and exit")
+	flag.Parse()
+
 	if err := config.LoadConfig(); err != nil {
 		return
 	}
@@ -35,6 +39,15 @@ func main() {
 	}
 	defer fabricSvc.Close()
 
+	if *migrateExam != "" {
+		migrated, err := fabricSvc.MigrateLegacyAnswers(*migrateExam)
+		if err != nil {
+			log.Fatalf("failed to migrate legacy answer keys of exam %s: %v", *migrateExam, err)
+		}
+		log.Printf("migrated %d answers of exam %s to composite keys", migrated, *migrateExam)
+		return
+	}
+
 	examAuditHandler := auditengine.NewExamAuditHandler(fabricSvc)
 
 	r := gin.Default()
@@ -0,0 +1,95 @@
+package scoring
+
+import (
+	"fmt"
+	"sort"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+)
+
+const (
+	CombinerMean         = "mean"
+	CombinerMax          = "max"
+	CombinerTopKMean     = "topk_mean"
+	CombinerFlaggedCount = "flagged_count"
+
+	defaultTopK = 3
+)
+
+// Combiner folds the per-question scores of a student pair into one pair-level score
+type Combiner func(questions []model.QuestionScore) float64
+
+// NewCombiner returns the combiner selected by cfg , defaulting to max when none is configured
+func NewCombiner(cfg model.AggregationConfig) (Combiner, error) {
+	switch cfg.Combiner {
+	case "", CombinerMax:
+		return maxCombiner, nil
+	case CombinerMean:
+		return meanCombiner, nil
+	case CombinerTopKMean:
+		k := cfg.TopK
+		if k < 0 {
+			return nil, fmt.Errorf("top_k must not be negative , got %d", k)
+		}
+		if k == 0 {
+			k = defaultTopK
+		}
+		return topKMeanCombiner(k), nil
+	case CombinerFlaggedCount:
+		return flaggedCountCombiner, nil
+	default:
+		return nil, fmt.Errorf("unknown combiner %s", cfg.Combiner)
+	}
+}
+
+func meanCombiner(questions []model.QuestionScore) float64 {
+	if len(questions) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, q := range questions {
+		sum += q.Score
+	}
+	return sum / float64(len(questions))
+}
+
+func maxCombiner(questions []model.QuestionScore) float64 {
+	var m float64
+	for _, q := range questions {
+		if q.Score > m {
+			m = q.Score
+		}
+	}
+	return m
+}
+
+func topKMeanCombiner(k int) Combiner {
+	return func(questions []model.QuestionScore) float64 {
+		if len(questions) == 0 {
+			return 0
+		}
+		scores := make([]float64, 0, len(questions))
+		for _, q := range questions {
+			scores = append(scores, q.Score)
+		}
+		sort.Sort(sort.Reverse(sort.Float64Slice(scores)))
+		if k < len(scores) {
+			scores = scores[:k]
+		}
+		var sum float64
+		for _, s := range scores {
+			sum += s
+		}
+		return sum / float64(len(scores))
+	}
+}
+
+func flaggedCountCombiner(questions []model.QuestionScore) float64 {
+	var count float64
+	for _, q := range questions {
+		if q.Flagged {
+			count++
+		}
+	}
+	return count
+}
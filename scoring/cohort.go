@@ -0,0 +1,136 @@
+package scoring
+
+import (
+	"math"
+	"strings"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+)
+
+// Cohort holds per-question distributions of final answers and revision paths across all students
+type Cohort struct {
+	students     int
+	answered     map[string]int
+	finalAnswers map[string]map[string]int
+	paths        map[string]map[string]int
+}
+
+// NewCohort computes the cohort statistics from the flattened answer table
+func NewCohort(studentAnswersMap map[string]map[string][]model.AnswerRevision) *Cohort {
+	c := &Cohort{
+		answered:     make(map[string]int),
+		finalAnswers: make(map[string]map[string]int),
+		paths:        make(map[string]map[string]int),
+		students:     len(studentAnswersMap),
+	}
+	for _, answers := range studentAnswersMap {
+		for qID, revisions := range answers {
+			if len(revisions) == 0 {
+				continue
+			}
+			if _, ok := c.finalAnswers[qID]; !ok {
+				c.finalAnswers[qID] = make(map[string]int)
+				c.paths[qID] = make(map[string]int)
+			}
+			c.answered[qID]++
+			c.finalAnswers[qID][revisions[len(revisions)-1].Ans]++
+			c.paths[qID][pathKey(revisions)]++
+		}
+	}
+	return c
+}
+
+// Update replaces the contribution of one student to qID , going from the before revisions to after . It
+// leaves the cohort exactly as NewCohort would build it from the updated table
+func (c *Cohort) Update(qID string, before, after []model.AnswerRevision) {
+	if len(before) > 0 {
+		decrement(c.answered, qID)
+		decrement(c.finalAnswers[qID], before[len(before)-1].Ans)
+		decrement(c.paths[qID], pathKey(before))
+	}
+	if len(after) > 0 {
+		if _, ok := c.finalAnswers[qID]; !ok {
+			c.finalAnswers[qID] = make(map[string]int)
+			c.paths[qID] = make(map[string]int)
+		}
+		c.answered[qID]++
+		c.finalAnswers[qID][after[len(after)-1].Ans]++
+		c.paths[qID][pathKey(after)]++
+	}
+}
+
+// AddStudent counts a student joining the cohort , their answers then arrive through Update
+func (c *Cohort) AddStudent() {
+	c.students++
+}
+
+// Answered returns how many students submitted at least one revision for qID
+func (c *Cohort) Answered(qID string) int {
+	return c.answered[qID]
+}
+
+// AnswerFrequency returns the share of students answering qID whose final answer is ans
+func (c *Cohort) AnswerFrequency(qID, ans string) float64 {
+	n := c.answered[qID]
+	if n == 0 {
+		return 0
+	}
+	return float64(c.finalAnswers[qID][ans]) / float64(n)
+}
+
+// PathFrequency returns the share of students answering qID who went through exactly the given revisions
+func (c *Cohort) PathFrequency(qID string, revisions []model.AnswerRevision) float64 {
+	n := c.answered[qID]
+	if n == 0 {
+		return 0
+	}
+	return float64(c.paths[qID][pathKey(revisions)]) / float64(n)
+}
+
+// SkipFrequency returns the share of all students who never answered qID
+func (c *Cohort) SkipFrequency(qID string) float64 {
+	if c.students == 0 {
+		return 0
+	}
+	return float64(c.students-c.answered[qID]) / float64(c.students)
+}
+
+// Rarity turns the frequency of something two students share into a normalized information content .
+// It is 1 when only the pair itself has it and 0 when the whole cohort does
+func (c *Cohort) Rarity(qID string, freq float64) float64 {
+	return rarity(float64(c.answered[qID]), freq)
+}
+
+// SkipRarity is Rarity for leaving qID blank , measured against every student rather than those answering
+func (c *Cohort) SkipRarity(qID string) float64 {
+	return rarity(float64(c.students), c.SkipFrequency(qID))
+}
+
+func rarity(n, freq float64) float64 {
+	if freq <= 0 {
+		return 0
+	}
+	floor := 2 / n
+	if floor >= 1 {
+		// the pair is the whole cohort , nothing to compare against
+		return 1
+	}
+	return math.Min(1, math.Max(0, math.Log(freq)/math.Log(floor)))
+}
+
+// decrement drops counts reaching zero so an updated cohort holds the same keys as a freshly built one
+func decrement(counts map[string]int, key string) {
+	if counts[key] <= 1 {
+		delete(counts, key)
+		return
+	}
+	counts[key]--
+}
+
+func pathKey(revisions []model.AnswerRevision) string {
+	parts := make([]string, 0, len(revisions))
+	for _, r := range revisions {
+		parts = append(parts, r.Ans)
+	}
+	return strings.Join(parts, "\x1f")
+}
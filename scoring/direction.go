@@ -0,0 +1,96 @@
+package scoring
+
+import (
+	"sort"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+)
+
+// Attribution is the inferred direction of copying between a pair . Source is LeaderA or LeaderB , or
+// empty when the evidence does not point either way
+type Attribution struct {
+	Source      string
+	Confidence  float64
+	FirstCommit [2]int
+	Convergence [2]int
+}
+
+// Attribute infers who copied from whom over the given questions . Three signals vote for a source :
+// who committed a shared final answer first , who changed their answer to one the other already held ,
+// and which side leads the edit timeline weighted by the strength of the lag . Confidence is the
+// winning margin over all votes cast
+func Attribute(a, b map[string][]model.AnswerRevision, questionIDs []string, lag Lag) Attribution {
+	var att Attribution
+	for _, qID := range questionIDs {
+		aRevisions, bRevisions := a[qID], b[qID]
+		if len(aRevisions) == 0 || len(bRevisions) == 0 {
+			continue
+		}
+
+		if final := aRevisions[len(aRevisions)-1].Ans; final == bRevisions[len(bRevisions)-1].Ans {
+			tA, tB := settledAt(aRevisions), settledAt(bRevisions)
+			switch {
+			case tA < tB:
+				att.FirstCommit[0]++
+			case tB < tA:
+				att.FirstCommit[1]++
+			}
+		}
+
+		att.Convergence[0] += convergences(bRevisions, aRevisions)
+		att.Convergence[1] += convergences(aRevisions, bRevisions)
+	}
+
+	votesA := float64(att.FirstCommit[0] + att.Convergence[0])
+	votesB := float64(att.FirstCommit[1] + att.Convergence[1])
+	switch lag.Leader() {
+	case LeaderA:
+		votesA += lag.Strength
+	case LeaderB:
+		votesB += lag.Strength
+	}
+
+	total := votesA + votesB
+	switch {
+	case total == 0 || votesA == votesB:
+		return att
+	case votesA > votesB:
+		att.Source = LeaderA
+		att.Confidence = (votesA - votesB) / total
+	default:
+		att.Source = LeaderB
+		att.Confidence = (votesB - votesA) / total
+	}
+	return att
+}
+
+// settledAt returns when the final answer was entered for the last time without changing afterwards
+func settledAt(revisions []model.AnswerRevision) int64 {
+	final := revisions[len(revisions)-1].Ans
+	at := revisions[len(revisions)-1].SubmittedAt
+	for i := len(revisions) - 2; i >= 0 && revisions[i].Ans == final; i-- {
+		at = revisions[i].SubmittedAt
+	}
+	return at
+}
+
+// convergences counts the revisions of follower that switch to the answer leader was holding at that time
+func convergences(follower, leader []model.AnswerRevision) int {
+	leaderTimes := make([]int64, len(leader))
+	for i, r := range leader {
+		leaderTimes[i] = r.SubmittedAt
+	}
+
+	var count int
+	for i, r := range follower {
+		if i > 0 && follower[i-1].Ans == r.Ans {
+			continue
+		}
+		// last leader revision strictly before the follower's change
+		idx := sort.Search(len(leaderTimes), func(j int) bool { return leaderTimes[j] >= r.SubmittedAt }) - 1
+		if idx >= 0 && leader[idx].Ans == r.Ans {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,96 @@
+package scoring
+
+import (
+	"fmt"
+	"strings"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+)
+
+// MatchRevisions aligns the revision sequences of two students using their longest common subsequence
+// of answers and reports the matched revisions together with the time between them , positive when B
+// submitted after A
+func MatchRevisions(a, b []model.AnswerRevision) []model.RevisionMatch {
+	if len(a) == 0 || len(b) == 0 {
+		return nil
+	}
+
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i].Ans == b[j].Ans {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var matches []model.RevisionMatch
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i].Ans == b[j].Ans:
+			matches = append(matches, model.RevisionMatch{
+				Ans:          a[i].Ans,
+				IndexA:       i,
+				IndexB:       j,
+				SubmittedAtA: a[i].SubmittedAt,
+				SubmittedAtB: b[j].SubmittedAt,
+				DeltaSeconds: b[j].SubmittedAt - a[i].SubmittedAt,
+			})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return matches
+}
+
+// Explain builds the evidence for one flagged question
+func Explain(in Input, res Result) model.QuestionEvidence {
+	return model.QuestionEvidence{
+		QuestionID:        in.QuestionID,
+		Score:             res.Score,
+		SubScores:         res.SubScores,
+		MatchingRevisions: MatchRevisions(in.A, in.B),
+	}
+}
+
+// Summarize renders a one line human readable description of a flagged pair
+func Summarize(item model.AdjacencyItem) string {
+	var parts []string
+	for _, ev := range item.Reason.Evidence {
+		part := fmt.Sprintf("%s (score %.2f", ev.QuestionID, ev.Score)
+		if n := len(ev.MatchingRevisions); n > 0 {
+			var maxDelta int64
+			for _, m := range ev.MatchingRevisions {
+				maxDelta = max(maxDelta, m.DeltaSeconds, -m.DeltaSeconds)
+			}
+			part += fmt.Sprintf(", %d matching revisions within %ds", n, maxDelta)
+		}
+		parts = append(parts, part+")")
+	}
+	summary := fmt.Sprintf("%s-%s: %.2f overall", item.StudentA, item.StudentB, item.Score)
+	if item.PValue > 0 {
+		summary += fmt.Sprintf(" (p = %.4f, q = %.4f)", item.PValue, item.QValue)
+	}
+	if len(parts) > 0 {
+		summary += ", flagged on " + strings.Join(parts, "; ")
+	}
+	if item.ExamFlagged {
+		summary += fmt.Sprintf(", flagged across the whole exam (score %.2f)", item.ExamScore)
+	}
+	if item.Direction != nil {
+		summary += fmt.Sprintf(", %s likely copied from %s (confidence %.2f)", item.Direction.Target, item.Direction.Source, item.Direction.Confidence)
+	}
+	if item.Lag != nil {
+		summary += fmt.Sprintf(", %s edits %ds after %s (strength %.2f)", item.Lag.Follower, item.Lag.LagSeconds, item.Lag.Leader, item.Lag.Strength)
+	}
+	return summary
+}
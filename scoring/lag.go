@@ -0,0 +1,146 @@
+package scoring
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+)
+
+const (
+	defaultMaxLagSeconds    = 60
+	defaultToleranceSeconds = 2
+	defaultMinLagSamples    = 2
+
+	LeaderA = "A"
+	LeaderB = "B"
+)
+
+// Lag describes the dominant delay between two edit timelines . A positive LagSeconds means B edits after A
+type Lag struct {
+	LagSeconds int64
+	Strength   float64
+	Samples    int
+}
+
+// Leader returns which side of the pair edits first , or an empty string when they edit together
+func (l Lag) Leader() string {
+	switch {
+	case l.Strength == 0 || l.LagSeconds == 0:
+		return ""
+	case l.LagSeconds > 0:
+		return LeaderA
+	default:
+		return LeaderB
+	}
+}
+
+// lagDeltas returns every signed delay b - a between the two timelines that is within maxLag
+func lagDeltas(aTime, bTime []int64, maxLag int64) []int64 {
+	var deltas []int64
+	for _, ta := range aTime {
+		for _, tb := range bTime {
+			if d := tb - ta; d >= -maxLag && d <= maxLag {
+				deltas = append(deltas, d)
+			}
+		}
+	}
+	return deltas
+}
+
+// dominantLag runs a time-lagged cross-correlation over the delays . Every candidate lag is scored by
+// how many delays fall within tolerance of it and the best lag wins , ties going to the shorter lag .
+// Strength is the share of the shorter timeline explained by that lag
+func dominantLag(deltas []int64, events int, tolerance int64) Lag {
+	if len(deltas) == 0 || events == 0 {
+		return Lag{}
+	}
+	sorted := append([]int64(nil), deltas...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var best Lag
+	bestCount := 0
+	for _, candidate := range sorted {
+		lo := sort.Search(len(sorted), func(i int) bool { return sorted[i] >= candidate-tolerance })
+		hi := sort.Search(len(sorted), func(i int) bool { return sorted[i] > candidate+tolerance })
+		count := hi - lo
+		if count > bestCount || (count == bestCount && abs(candidate) < abs(best.LagSeconds)) {
+			bestCount = count
+			best.LagSeconds = candidate
+		}
+	}
+	best.Samples = bestCount
+	best.Strength = math.Min(1, float64(bestCount)/float64(events))
+	return best
+}
+
+// TimelineLag finds the dominant lag between two students across all the questions they both answered .
+// Delays are only measured between revisions of the same question
+func TimelineLag(a, b map[string][]model.AnswerRevision, cfg model.LagConfig) Lag {
+	maxLag, tolerance := lagWindow(cfg)
+
+	var deltas []int64
+	var eventsA, eventsB int
+	for qID, aRevisions := range a {
+		bRevisions, ok := b[qID]
+		if !ok || len(aRevisions) == 0 || len(bRevisions) == 0 {
+			continue
+		}
+		eventsA += len(aRevisions)
+		eventsB += len(bRevisions)
+		deltas = append(deltas, lagDeltas(timestamps(aRevisions), timestamps(bRevisions), maxLag)...)
+	}
+	return dominantLag(deltas, min(eventsA, eventsB), tolerance)
+}
+
+func lagWindow(cfg model.LagConfig) (int64, int64) {
+	maxLag, tolerance := cfg.MaxLagSeconds, int64(defaultToleranceSeconds)
+	if maxLag <= 0 {
+		maxLag = defaultMaxLagSeconds
+	}
+	if cfg.ToleranceSeconds != nil {
+		tolerance = *cfg.ToleranceSeconds
+	}
+	return maxLag, tolerance
+}
+
+// LagConsistency scores how consistently one timeline follows the other , the share of revisions explained by
+// the dominant lag scaled down while fewer than MinSamples of them agree . How long the lag is does not matter ,
+// a copier working half a minute behind is as suspicious as one working two seconds behind
+func LagConsistency(lag Lag, cfg model.LagConfig) float64 {
+	minSamples := cfg.MinSamples
+	if minSamples <= 0 {
+		minSamples = defaultMinLagSamples
+	}
+	return lag.Strength * math.Min(1, float64(lag.Samples)/float64(minSamples))
+}
+
+// ValidateLag rejects a lag window that can't be measured
+func ValidateLag(cfg model.LagConfig) error {
+	if cfg.MaxLagSeconds < 0 {
+		return fmt.Errorf("max_lag_seconds must not be negative , got %d", cfg.MaxLagSeconds)
+	}
+	if cfg.ToleranceSeconds != nil && *cfg.ToleranceSeconds < 0 {
+		return fmt.Errorf("tolerance_seconds must not be negative , got %d", *cfg.ToleranceSeconds)
+	}
+	if cfg.MinSamples < 0 {
+		return fmt.Errorf("min_samples must not be negative , got %d", cfg.MinSamples)
+	}
+	return nil
+}
+
+func timestamps(revisions []model.AnswerRevision) []int64 {
+	out := make([]int64, 0, len(revisions))
+	for _, r := range revisions {
+		out = append(out, r.SubmittedAt)
+	}
+	return out
+}
+
+func abs(x int64) int64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
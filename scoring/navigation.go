@@ -0,0 +1,133 @@
+package scoring
+
+import (
+	"sort"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+)
+
+const NavigationOrderName = "navigation_order"
+
+func init() {
+	RegisterExam(navigationOrderScorer{})
+}
+
+// Navigation is the path a student took through the exam rebuilt from revision timestamps
+type Navigation struct {
+	// FirstTouch lists question ids in the order they were first answered
+	FirstTouch []string
+	// Revisits lists question ids every time the student came back to an earlier question
+	Revisits []string
+}
+
+// NavigationComparison compares the navigation of two students
+type NavigationComparison struct {
+	// Tau is the Kendall rank correlation of the first touch orders over the questions both touched
+	Tau float64
+	// Typicality is how closely the pair simply follows the presented exam order
+	Typicality float64
+	// RevisitAlignment compares the sequences of revisits , zero when either student never went back
+	RevisitAlignment float64
+}
+
+// navigationOrderScorer flags students who move through the exam in the same unusual order . Agreement
+// on first touch order is discounted by how closely both follow the presented order , since walking the
+// exam front to back is what most students do
+type navigationOrderScorer struct{}
+
+func (navigationOrderScorer) Name() string { return NavigationOrderName }
+
+func (navigationOrderScorer) ScoreExam(in ExamInput) float64 {
+	c := CompareNavigation(in)
+	order := max(0, c.Tau) * (1 - c.Typicality)
+	if c.RevisitAlignment == 0 {
+		return order
+	}
+	return (order + c.RevisitAlignment) / 2
+}
+
+// BuildNavigation orders all revisions of a student by time and splits the visits into first touches and revisits
+func BuildNavigation(answers map[string][]model.AnswerRevision) Navigation {
+	type visit struct {
+		qID string
+		at  int64
+	}
+	var visits []visit
+	for qID, revisions := range answers {
+		for _, r := range revisions {
+			visits = append(visits, visit{qID: qID, at: r.SubmittedAt})
+		}
+	}
+	sort.Slice(visits, func(i, j int) bool {
+		if visits[i].at != visits[j].at {
+			return visits[i].at < visits[j].at
+		}
+		return visits[i].qID < visits[j].qID
+	})
+
+	var nav Navigation
+	seen := make(map[string]bool)
+	last := ""
+	for _, v := range visits {
+		if v.qID == last {
+			continue
+		}
+		last = v.qID
+		if seen[v.qID] {
+			nav.Revisits = append(nav.Revisits, v.qID)
+			continue
+		}
+		seen[v.qID] = true
+		nav.FirstTouch = append(nav.FirstTouch, v.qID)
+	}
+	return nav
+}
+
+// CompareNavigation compares the navigation sequences of both students of in
+func CompareNavigation(in ExamInput) NavigationComparison {
+	navA, navB := BuildNavigation(in.A), BuildNavigation(in.B)
+
+	presented := make([]string, 0, len(in.Questions))
+	for _, q := range ExamOrder(in) {
+		presented = append(presented, q.QuestionID)
+	}
+
+	var c NavigationComparison
+	c.Tau = KendallTau(navA.FirstTouch, navB.FirstTouch)
+	c.Typicality = (max(0, KendallTau(navA.FirstTouch, presented)) + max(0, KendallTau(navB.FirstTouch, presented))) / 2
+	if len(navA.Revisits) > 0 && len(navB.Revisits) > 0 {
+		c.RevisitAlignment = sequenceSimilarity(navA.Revisits, navB.Revisits)
+	}
+	return c
+}
+
+// KendallTau returns the rank correlation in [-1,1] of two orderings over the items they share . Fewer
+// than two shared items carry no ordering information and give zero
+func KendallTau(a, b []string) float64 {
+	rankB := make(map[string]int, len(b))
+	for i, id := range b {
+		rankB[id] = i
+	}
+	var ranks []int
+	for _, id := range a {
+		if r, ok := rankB[id]; ok {
+			ranks = append(ranks, r)
+		}
+	}
+	n := len(ranks)
+	if n < 2 {
+		return 0
+	}
+
+	var concordant, discordant int
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if ranks[i] < ranks[j] {
+				concordant++
+			} else {
+				discordant++
+			}
+		}
+	}
+	return float64(concordant-discordant) / float64(n*(n-1)/2)
+}
@@ -0,0 +1,29 @@
+package scoring
+
+const AnswerRarityName = "answer_rarity"
+
+func init() {
+	Register(answerRarityScorer{})
+}
+
+// answerRarityScorer scores a shared final answer or shared revision path by how improbable it is in
+// the cohort , so agreeing on what 2% of the class picked weighs far more than agreeing on what 90% picked
+type answerRarityScorer struct{}
+
+func (answerRarityScorer) Name() string { return AnswerRarityName }
+
+func (answerRarityScorer) Score(in Input) float64 {
+	if in.Cohort == nil || len(in.A) == 0 || len(in.B) == 0 {
+		return 0
+	}
+	finalA := in.A[len(in.A)-1].Ans
+	if finalA != in.B[len(in.B)-1].Ans {
+		return 0
+	}
+
+	score := in.Cohort.Rarity(in.QuestionID, in.Cohort.AnswerFrequency(in.QuestionID, finalA))
+	if len(in.A) > 1 && pathKey(in.A) == pathKey(in.B) {
+		score = max(score, in.Cohort.Rarity(in.QuestionID, in.Cohort.PathFrequency(in.QuestionID, in.A)))
+	}
+	return score
+}
@@ -0,0 +1,239 @@
+package scoring
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+)
+
+// Input carries everything a scorer may look at when comparing two students on a question
+type Input struct {
+	QuestionID string
+	Question   model.Question
+	A          []model.AnswerRevision
+	B          []model.AnswerRevision
+	Cohort     *Cohort
+}
+
+// Scorer produces a similarity signal in the range [0,1] for a pair of students on one question
+type Scorer interface {
+	Name() string
+	Score(in Input) float64
+}
+
+// ExamInput carries the whole exam for scorers that compare two students across all questions at once
+type ExamInput struct {
+	// Questions is the exam in the order it was presented
+	Questions []model.Question
+	A         map[string][]model.AnswerRevision
+	B         map[string][]model.AnswerRevision
+	Cohort    *Cohort
+}
+
+// ExamScorer produces a similarity signal in the range [0,1] for a pair of students over the whole exam
+type ExamScorer interface {
+	Name() string
+	ScoreExam(in ExamInput) float64
+}
+
+// Configurable is implemented by scorers that accept tuning parameters from the scorer config
+type Configurable interface {
+	Configure(params map[string]float64) (Scorer, error)
+}
+
+// lagScorer is implemented by scorers reading the shared lag window of the scoring config
+type lagScorer interface {
+	withLag(cfg model.LagConfig) Scorer
+}
+
+// skipRateScorer is implemented by exam-level scorers reading the cohort skip rates
+type skipRateScorer interface {
+	readsSkipRates()
+}
+
+var (
+	registryMu   sync.RWMutex
+	registry     = make(map[string]Scorer)
+	examRegistry = make(map[string]ExamScorer)
+)
+
+// Register makes a scorer available to pipelines under its name
+func Register(s Scorer) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[s.Name()] = s
+}
+
+// RegisterExam makes an exam-level scorer available to pipelines under its name
+func RegisterExam(s ExamScorer) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	examRegistry[s.Name()] = s
+}
+
+// LookupExam returns the registered exam-level scorer with the given name
+func LookupExam(name string) (ExamScorer, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	s, ok := examRegistry[name]
+	return s, ok
+}
+
+// Lookup returns the registered scorer with the given name
+func Lookup(name string) (Scorer, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	s, ok := registry[name]
+	return s, ok
+}
+
+// Registered returns the names of all registered scorers in sorted order
+func Registered() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry)+len(examRegistry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	for name := range examRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultConfig mirrors the original hardcoded questionScore weights
+func DefaultConfig() model.ScoringConfig {
+	return model.ScoringConfig{
+		Scorers: []model.ScorerConfig{
+			{Name: AnswerSimilarityName, Weight: 0.5, Enabled: true},
+			{Name: TimeCorrelationName, Weight: 0.3, Enabled: true},
+			{Name: EditPatternName, Weight: 0.2, Enabled: true},
+		},
+	}
+}
+
+type weightedScorer struct {
+	scorer Scorer
+	weight float64
+}
+
+type weightedExamScorer struct {
+	scorer ExamScorer
+	weight float64
+}
+
+// Pipeline combines the enabled scorers into a single weighted score
+type Pipeline struct {
+	scorers         []weightedScorer
+	totalWeight     float64
+	examScorers     []weightedExamScorer
+	examTotalWeight float64
+	skipRates       bool
+}
+
+// NewPipeline builds a pipeline from cfg , falling back to the scorers of DefaultConfig when none are configured
+func NewPipeline(cfg model.ScoringConfig) (*Pipeline, error) {
+	if len(cfg.Scorers) == 0 {
+		cfg.Scorers = DefaultConfig().Scorers
+	}
+
+	if err := ValidateLag(cfg.Lag); err != nil {
+		return nil, fmt.Errorf("invalid lag config: %w", err)
+	}
+
+	p := &Pipeline{}
+	for _, sc := range cfg.Scorers {
+		if !sc.Enabled {
+			continue
+		}
+		if sc.Weight < 0 {
+			return nil, fmt.Errorf("scorer %s has negative weight %f", sc.Name, sc.Weight)
+		}
+		if es, ok := LookupExam(sc.Name); ok {
+			if len(sc.Params) > 0 {
+				return nil, fmt.Errorf("scorer %s takes no params , got %v", sc.Name, sc.Params)
+			}
+			p.examScorers = append(p.examScorers, weightedExamScorer{scorer: es, weight: sc.Weight})
+			p.examTotalWeight += sc.Weight
+			if _, ok := es.(skipRateScorer); ok {
+				p.skipRates = true
+			}
+			continue
+		}
+		s, ok := Lookup(sc.Name)
+		if !ok {
+			return nil, fmt.Errorf("unknown scorer %s , registered scorers - %v", sc.Name, Registered())
+		}
+		if c, ok := s.(Configurable); ok && len(sc.Params) > 0 {
+			configured, err := c.Configure(sc.Params)
+			if err != nil {
+				return nil, fmt.Errorf("failed to configure scorer %s: %w", sc.Name, err)
+			}
+			s = configured
+		}
+		if l, ok := s.(lagScorer); ok {
+			s = l.withLag(cfg.Lag)
+		}
+		p.scorers = append(p.scorers, weightedScorer{scorer: s, weight: sc.Weight})
+		p.totalWeight += sc.Weight
+	}
+
+	if p.totalWeight == 0 && p.examTotalWeight == 0 {
+		return nil, fmt.Errorf("scoring config has no enabled scorers with a positive weight")
+	}
+	return p, nil
+}
+
+// Result is the combined score along with the raw output of each scorer keyed by scorer name
+type Result struct {
+	Score     float64
+	SubScores map[string]float64
+}
+
+// Score returns the weighted mean of all enabled scorers for in
+func (p *Pipeline) Score(in Input) float64 {
+	return p.Evaluate(in).Score
+}
+
+// Evaluate runs every enabled scorer and keeps the individual sub-scores for explanation
+func (p *Pipeline) Evaluate(in Input) Result {
+	res := Result{SubScores: make(map[string]float64, len(p.scorers))}
+	var sum float64
+	for _, ws := range p.scorers {
+		s := ws.scorer.Score(in)
+		res.SubScores[ws.scorer.Name()] = s
+		sum += ws.weight * s
+	}
+	if p.totalWeight > 0 {
+		res.Score = sum / p.totalWeight
+	}
+	return res
+}
+
+// HasExamScorers reports whether any exam-level scorer is enabled
+func (p *Pipeline) HasExamScorers() bool {
+	return len(p.examScorers) > 0
+}
+
+// ReadsSkipRates reports whether an enabled exam-level scorer depends on the cohort skip rates
+func (p *Pipeline) ReadsSkipRates() bool {
+	return p.skipRates
+}
+
+// EvaluateExam runs every enabled exam-level scorer over the whole exam
+func (p *Pipeline) EvaluateExam(in ExamInput) Result {
+	res := Result{SubScores: make(map[string]float64, len(p.examScorers))}
+	var sum float64
+	for _, ws := range p.examScorers {
+		s := ws.scorer.ScoreExam(in)
+		res.SubScores[ws.scorer.Name()] = s
+		sum += ws.weight * s
+	}
+	if p.examTotalWeight > 0 {
+		res.Score = sum / p.examTotalWeight
+	}
+	return res
+}
@@ -0,0 +1,154 @@
+package scoring
+
+import (
+	"fmt"
+	"math"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+)
+
+const (
+	AnswerSimilarityName = "answer_similarity"
+	TimeCorrelationName  = "time_correlation"
+	EditPatternName      = "edit_pattern"
+
+	// defaultCorrectMatchWeight discounts two students agreeing on the key , which is expected of
+	// anyone who knows the material , so that shared wrong answers dominate the signal
+	defaultCorrectMatchWeight = 0.25
+)
+
+func init() {
+	Register(answerSimilarityScorer{correctMatchWeight: defaultCorrectMatchWeight})
+	Register(timeCorrelationScorer{})
+	Register(editPatternScorer{correctMatchWeight: defaultCorrectMatchWeight})
+}
+
+// answerSimilarityScorer compares the final answers of both students with the comparator for the question
+// type . When the question carries an answer key , a match on the correct answer is scaled down by
+// correctMatchWeight
+type answerSimilarityScorer struct {
+	correctMatchWeight float64
+}
+
+func (answerSimilarityScorer) Name() string { return AnswerSimilarityName }
+
+func (s answerSimilarityScorer) Configure(params map[string]float64) (Scorer, error) {
+	w, err := correctMatchWeightParam(params, s.correctMatchWeight)
+	if err != nil {
+		return nil, err
+	}
+	s.correctMatchWeight = w
+	return s, nil
+}
+
+func (s answerSimilarityScorer) Score(in Input) float64 {
+	if len(in.A) == 0 || len(in.B) == 0 {
+		return 0
+	}
+	finalA := in.A[len(in.A)-1].Ans
+	finalB := in.B[len(in.B)-1].Ans
+	score := ComparatorFor(in.Question)(finalA, finalB)
+	if IsCorrect(in.Question, finalA) && IsCorrect(in.Question, finalB) {
+		score *= s.correctMatchWeight
+	}
+	return score
+}
+
+func correctMatchWeightParam(params map[string]float64, current float64) (float64, error) {
+	w, ok := params["correct_match_weight"]
+	if !ok {
+		return current, nil
+	}
+	if w < 0 || w > 1 {
+		return 0, fmt.Errorf("correct_match_weight must be within [0,1] , got %f", w)
+	}
+	return w, nil
+}
+
+// IsCorrect reports whether ans matches the answer key of q . Questions without a key are never correct
+func IsCorrect(q model.Question, ans string) bool {
+	if q.CorrectAnswer == "" {
+		return false
+	}
+	return ComparatorFor(q)(ans, q.CorrectAnswer) == 1
+}
+
+// timeCorrelationScorer measures how consistently one student's revisions follow the other's by a fixed
+// delay . The lag window comes from scoring.lag , shared with the lag reported on flagged pairs
+type timeCorrelationScorer struct {
+	lag model.LagConfig
+}
+
+func (timeCorrelationScorer) Name() string { return TimeCorrelationName }
+
+// Configure refuses every parameter , a second lag window here would drift from scoring.lag
+func (s timeCorrelationScorer) Configure(params map[string]float64) (Scorer, error) {
+	return nil, fmt.Errorf("%s takes no params , configure the lag window under scoring.lag", TimeCorrelationName)
+}
+
+func (s timeCorrelationScorer) withLag(cfg model.LagConfig) Scorer {
+	s.lag = cfg
+	return s
+}
+
+func (s timeCorrelationScorer) Score(in Input) float64 {
+	return timeCorrelation(timestamps(in.A), timestamps(in.B), s.lag)
+}
+
+// editPatternScorer compares the sequence of intermediate answers . Revisions that both students set to
+// the correct answer count for correctMatchWeight of a full match
+type editPatternScorer struct {
+	correctMatchWeight float64
+}
+
+func (editPatternScorer) Name() string { return EditPatternName }
+
+func (s editPatternScorer) Configure(params map[string]float64) (Scorer, error) {
+	w, err := correctMatchWeightParam(params, s.correctMatchWeight)
+	if err != nil {
+		return nil, err
+	}
+	s.correctMatchWeight = w
+	return s, nil
+}
+
+func (s editPatternScorer) Score(in Input) float64 {
+	var aEdits, bEdits []string
+	for _, r := range in.A {
+		aEdits = append(aEdits, r.Ans)
+	}
+	for _, r := range in.B {
+		bEdits = append(bEdits, r.Ans)
+	}
+	return editPatternScore(aEdits, bEdits, func(ans string) float64 {
+		if IsCorrect(in.Question, ans) {
+			return s.correctMatchWeight
+		}
+		return 1
+	})
+}
+
+func timeCorrelation(aTime, bTime []int64, cfg model.LagConfig) float64 {
+	maxLag, tolerance := lagWindow(cfg)
+	lag := dominantLag(lagDeltas(aTime, bTime, maxLag), min(len(aTime), len(bTime)), tolerance)
+	return LagConsistency(lag, cfg)
+}
+
+func editPatternScore(aEdits, bEdits []string, matchWeight func(ans string) float64) float64 {
+	minLen := int(math.Min(float64(len(aEdits)), float64(len(bEdits))))
+	if minLen == 0 {
+		return 0
+	}
+
+	// compare the last minLen edits of both students , the longer history's early edits have no counterpart
+	aEdits = aEdits[len(aEdits)-minLen:]
+	bEdits = bEdits[len(bEdits)-minLen:]
+
+	var match float64
+	for i := minLen - 1; i >= 0; i-- {
+		if aEdits[i] == bEdits[i] {
+			match += matchWeight(aEdits[i])
+		}
+	}
+	return match / float64(minLen)
+}
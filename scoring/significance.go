@@ -0,0 +1,208 @@
+package scoring
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+)
+
+const (
+	defaultPermutations = 1000
+	defaultFDR          = 0.05
+	defaultSeed         = 1
+)
+
+// PairScoreFunc returns the pair-level score of two students given their answers keyed by question id
+type PairScoreFunc func(a, b map[string][]model.AnswerRevision) float64
+
+// nullShardSize is the number of permutations drawn from one seeded generator . Shards don't depend on the
+// worker count , so the null distribution is the same however many workers build it
+const nullShardSize = 256
+
+// NullDistribution builds a baseline of pair scores by pairing synthetic students whose answers for
+// every question are drawn from different , randomly chosen members of pool . Mixing revisions across
+// students who are not linked to each other keeps the per-question behaviour of the cohort but breaks
+// any real copying relationship . Permutations are split into seeded shards scored by a bounded pool of
+// workers , the build stops early when ctx is cancelled . The returned scores are sorted ascending
+func NullDistribution(ctx context.Context, cfg model.SignificanceConfig, studentAnswersMap map[string]map[string][]model.AnswerRevision, pool []string, workers int, score PairScoreFunc) ([]float64, error) {
+	if len(pool) < 2 {
+		return nil, nil
+	}
+	permutations := cfg.Permutations
+	if permutations <= 0 {
+		permutations = defaultPermutations
+	}
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = defaultSeed
+	}
+
+	questionSet := make(map[string]struct{})
+	for _, answers := range studentAnswersMap {
+		for qID := range answers {
+			questionSet[qID] = struct{}{}
+		}
+	}
+	questionIDs := make([]string, 0, len(questionSet))
+	for qID := range questionSet {
+		questionIDs = append(questionIDs, qID)
+	}
+	sort.Strings(questionIDs)
+
+	shards := (permutations + nullShardSize - 1) / nullShardSize
+	seeds := make([]int64, shards)
+	master := rand.New(rand.NewSource(seed))
+	for k := range seeds {
+		seeds[k] = master.Int63()
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = max(1, min(workers, shards))
+
+	null := make([]float64, permutations)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := range jobs {
+				rng := rand.New(rand.NewSource(seeds[k]))
+				for p := k * nullShardSize; p < min((k+1)*nullShardSize, permutations); p++ {
+					if ctx.Err() != nil {
+						break
+					}
+					null[p] = score(drawPair(rng, studentAnswersMap, pool, questionIDs))
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for k := 0; k < shards; k++ {
+		select {
+		case jobs <- k:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("significance testing cancelled: %w", err)
+	}
+	sort.Float64s(null)
+	return null, nil
+}
+
+// drawPair builds one synthetic pair , each question of each side answered by a different member of pool
+func drawPair(rng *rand.Rand, studentAnswersMap map[string]map[string][]model.AnswerRevision, pool, questionIDs []string) (a, b map[string][]model.AnswerRevision) {
+	a = make(map[string][]model.AnswerRevision, len(questionIDs))
+	b = make(map[string][]model.AnswerRevision, len(questionIDs))
+	for _, qID := range questionIDs {
+		x := rng.Intn(len(pool))
+		y := rng.Intn(len(pool) - 1)
+		if y >= x {
+			y++
+		}
+		if revs := studentAnswersMap[pool[x]][qID]; len(revs) > 0 {
+			a[qID] = revs
+		}
+		if revs := studentAnswersMap[pool[y]][qID]; len(revs) > 0 {
+			b[qID] = revs
+		}
+	}
+	return a, b
+}
+
+// PValue returns the empirical probability of a null score at least as large as observed . The null
+// must be sorted ascending . One is added to both counts so the estimate is never zero
+func PValue(null []float64, observed float64) float64 {
+	idx := sort.SearchFloat64s(null, observed)
+	atLeast := len(null) - idx
+	return float64(atLeast+1) / float64(len(null)+1)
+}
+
+// BenjaminiHochberg converts p-values into q-values controlling the false discovery rate . The returned
+// slice is index aligned with pValues
+func BenjaminiHochberg(pValues []float64) []float64 {
+	m := len(pValues)
+	order := make([]int, m)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(x, y int) bool { return pValues[order[x]] < pValues[order[y]] })
+
+	q := make([]float64, m)
+	running := 1.0
+	for rank := m; rank >= 1; rank-- {
+		i := order[rank-1]
+		v := pValues[i] * float64(m) / float64(rank)
+		if v < running {
+			running = v
+		}
+		q[i] = running
+	}
+	return q
+}
+
+// Permutations returns how many synthetic pairs the null distribution needs for tests pair comparisons . An
+// empirical p-value is never below 1/(N+1) , which Benjamini-Hochberg scales by tests , so N is raised to more
+// than tests/FDR for a pair beating every null pair to survive on its own . Errors when that takes more than
+// MaxPermutations
+func Permutations(cfg model.SignificanceConfig, tests int) (int, error) {
+	permutations := cfg.Permutations
+	if permutations <= 0 {
+		permutations = defaultPermutations
+	}
+	needed := int(math.Ceil(float64(tests) / FDR(cfg)))
+	if needed > permutations {
+		permutations = needed
+	}
+	if cfg.MaxPermutations > 0 && permutations > cfg.MaxPermutations {
+		return cfg.MaxPermutations, fmt.Errorf("significance testing of %d pairs at fdr %g needs %d permutations , more than max_permutations %d", tests, FDR(cfg), permutations, cfg.MaxPermutations)
+	}
+	return permutations, nil
+}
+
+// ValidateSignificance checks the significance settings on their own , the cohort size is checked by Permutations
+func ValidateSignificance(cfg model.SignificanceConfig) error {
+	if cfg.Permutations < 0 {
+		return fmt.Errorf("significance permutations must not be negative , got %d", cfg.Permutations)
+	}
+	if cfg.FDR < 0 || cfg.FDR >= 1 {
+		return fmt.Errorf("significance fdr must be within (0,1) , got %g", cfg.FDR)
+	}
+	if cfg.MaxPermutations < 0 {
+		return fmt.Errorf("significance max_permutations must not be negative , got %d", cfg.MaxPermutations)
+	}
+	if cfg.MaxPermutations > 0 && cfg.Permutations > cfg.MaxPermutations {
+		return fmt.Errorf("significance permutations %d exceed max_permutations %d", cfg.Permutations, cfg.MaxPermutations)
+	}
+	return nil
+}
+
+// ValidateSignificanceCombiner rejects combiners under which a permutation test can't tell pairs apart . The max
+// of the per-question scores is one for almost every pair sharing any answer , random pairs included
+func ValidateSignificanceCombiner(agg model.AggregationConfig) error {
+	if agg.Combiner == "" || agg.Combiner == CombinerMax {
+		return fmt.Errorf("significance testing needs a combiner other than %s , whose pair scores saturate", CombinerMax)
+	}
+	return nil
+}
+
+// FDR returns the configured false discovery rate or the default when unset
+func FDR(cfg model.SignificanceConfig) float64 {
+	if cfg.FDR <= 0 {
+		return defaultFDR
+	}
+	return cfg.FDR
+}
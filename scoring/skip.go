@@ -0,0 +1,79 @@
+package scoring
+
+import model "github.com/deeraj-kumar/exam-audit/domain"
+
+const SkipPatternName = "skip_pattern"
+
+func init() {
+	RegisterExam(skipPatternScorer{})
+}
+
+// SkipComparison compares the questions two students left unanswered
+type SkipComparison struct {
+	// SkippedA and SkippedB list the question ids each student never answered , in exam order
+	SkippedA []string
+	SkippedB []string
+	// Shared is the number of questions both skipped
+	Shared int
+	// Jaccard is Shared over the number of questions either skipped , zero when neither skipped any
+	Jaccard float64
+	// Weighted is Jaccard with every question weighted by how rarely the cohort skipped it , so leaving the
+	// same hard question blank as half the class counts for little . It equals Jaccard without a cohort
+	Weighted float64
+}
+
+// skipPatternScorer flags students who leave the same rarely skipped questions blank . Skips are only known
+// against the exam definition , so the score is zero without one
+type skipPatternScorer struct{}
+
+func (skipPatternScorer) Name() string { return SkipPatternName }
+
+func (skipPatternScorer) readsSkipRates() {}
+
+func (skipPatternScorer) ScoreExam(in ExamInput) float64 {
+	return CompareSkips(in).Weighted
+}
+
+// CompareSkips lists the questions of the exam each student of in skipped and how much the two lists overlap
+func CompareSkips(in ExamInput) SkipComparison {
+	c := SkipComparison{SkippedA: Skipped(in.Questions, in.A), SkippedB: Skipped(in.Questions, in.B)}
+	weight := func(qID string) float64 {
+		if in.Cohort == nil {
+			return 1
+		}
+		return in.Cohort.SkipRarity(qID)
+	}
+
+	inA := make(map[string]bool, len(c.SkippedA))
+	var shared, either float64
+	for _, qID := range c.SkippedA {
+		inA[qID] = true
+		either += weight(qID)
+	}
+	for _, qID := range c.SkippedB {
+		if inA[qID] {
+			c.Shared++
+			shared += weight(qID)
+			continue
+		}
+		either += weight(qID)
+	}
+	if n := len(c.SkippedA) + len(c.SkippedB) - c.Shared; n > 0 {
+		c.Jaccard = float64(c.Shared) / float64(n)
+	}
+	if either > 0 {
+		c.Weighted = shared / either
+	}
+	return c
+}
+
+// Skipped lists the questions of the exam a student never answered , in exam order
+func Skipped(questions []model.Question, answers map[string][]model.AnswerRevision) []string {
+	var out []string
+	for _, q := range questions {
+		if len(answers[q.QuestionID]) == 0 {
+			out = append(out, q.QuestionID)
+		}
+	}
+	return out
+}
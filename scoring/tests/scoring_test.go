@@ -0,0 +1,643 @@
+package scoring_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+	"github.com/deeraj-kumar/exam-audit/scoring"
+	"github.com/stretchr/testify/assert"
+)
+
+type constantScorer struct {
+	name  string
+	value float64
+}
+
+func (c constantScorer) Name() string                   { return c.name }
+func (c constantScorer) Score(in scoring.Input) float64 { return c.value }
+
+func TestNewPipeline_DefaultWeights(t *testing.T) {
+	p, err := scoring.NewPipeline(model.ScoringConfig{})
+	assert.Nil(t, err)
+
+	in := scoring.Input{
+		QuestionID: "q1",
+		A:          []model.AnswerRevision{{SubmittedAt: 100, Ans: "B"}, {SubmittedAt: 130, Ans: "A"}},
+		B:          []model.AnswerRevision{{SubmittedAt: 100, Ans: "B"}, {SubmittedAt: 130, Ans: "A"}},
+	}
+	assert.InDelta(t, 1.0, p.Score(in), 1e-9)
+}
+
+func TestNewPipeline_UnknownScorer(t *testing.T) {
+	_, err := scoring.NewPipeline(model.ScoringConfig{
+		Scorers: []model.ScorerConfig{{Name: "does_not_exist", Weight: 1, Enabled: true}},
+	})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "does_not_exist")
+}
+
+func TestNewPipeline_ExamScorerParams(t *testing.T) {
+	_, err := scoring.NewPipeline(model.ScoringConfig{
+		Scorers: []model.ScorerConfig{{Name: scoring.SkipPatternName, Weight: 1, Enabled: true, Params: map[string]float64{"threshold": 0.5}}},
+	})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), scoring.SkipPatternName)
+}
+
+func TestNewPipeline_NoEnabledScorers(t *testing.T) {
+	_, err := scoring.NewPipeline(model.ScoringConfig{
+		Scorers: []model.ScorerConfig{{Name: scoring.AnswerSimilarityName, Weight: 1, Enabled: false}},
+	})
+	assert.NotNil(t, err)
+}
+
+func TestPipeline_CustomScorerWeighted(t *testing.T) {
+	scoring.Register(constantScorer{name: "test_constant_one", value: 1})
+	scoring.Register(constantScorer{name: "test_constant_zero", value: 0})
+
+	p, err := scoring.NewPipeline(model.ScoringConfig{
+		Scorers: []model.ScorerConfig{
+			{Name: "test_constant_one", Weight: 3, Enabled: true},
+			{Name: "test_constant_zero", Weight: 1, Enabled: true},
+			{Name: scoring.AnswerSimilarityName, Weight: 10, Enabled: false},
+		},
+	})
+	assert.Nil(t, err)
+	assert.InDelta(t, 0.75, p.Score(scoring.Input{}), 1e-9)
+}
+
+func TestNewCombiner(t *testing.T) {
+	questions := []model.QuestionScore{
+		{QuestionID: "q1", Score: 0.9, Flagged: true},
+		{QuestionID: "q2", Score: 0.2},
+		{QuestionID: "q3", Score: 0.8, Flagged: true},
+		{QuestionID: "q4", Score: 0.1},
+	}
+
+	tests := []struct {
+		cfg  model.AggregationConfig
+		want float64
+	}{
+		{cfg: model.AggregationConfig{}, want: 0.9},
+		{cfg: model.AggregationConfig{Combiner: scoring.CombinerMax}, want: 0.9},
+		{cfg: model.AggregationConfig{Combiner: scoring.CombinerMean}, want: 0.5},
+		{cfg: model.AggregationConfig{Combiner: scoring.CombinerTopKMean, TopK: 2}, want: 0.85},
+		{cfg: model.AggregationConfig{Combiner: scoring.CombinerFlaggedCount}, want: 2},
+	}
+	for _, tc := range tests {
+		combine, err := scoring.NewCombiner(tc.cfg)
+		assert.Nil(t, err)
+		assert.InDelta(t, tc.want, combine(questions), 1e-9, "combiner %q", tc.cfg.Combiner)
+	}
+
+	_, err := scoring.NewCombiner(model.AggregationConfig{Combiner: "median"})
+	assert.NotNil(t, err)
+}
+
+func TestMatchRevisions(t *testing.T) {
+	a := []model.AnswerRevision{
+		{SubmittedAt: 100, Ans: "Option B"},
+		{SubmittedAt: 110, Ans: "Option A"},
+		{SubmittedAt: 130, Ans: "Option C"},
+	}
+	b := []model.AnswerRevision{
+		{SubmittedAt: 103, Ans: "Option B"},
+		{SubmittedAt: 135, Ans: "Option C"},
+	}
+
+	matches := scoring.MatchRevisions(a, b)
+	assert.Equal(t, []model.RevisionMatch{
+		{Ans: "Option B", IndexA: 0, IndexB: 0, SubmittedAtA: 100, SubmittedAtB: 103, DeltaSeconds: 3},
+		{Ans: "Option C", IndexA: 2, IndexB: 1, SubmittedAtA: 130, SubmittedAtB: 135, DeltaSeconds: 5},
+	}, matches)
+
+	assert.Empty(t, scoring.MatchRevisions(a, nil))
+
+	// the delta keeps its sign , B answering first is negative
+	swapped := scoring.MatchRevisions(b, a)
+	assert.Equal(t, int64(-3), swapped[0].DeltaSeconds)
+	assert.Equal(t, int64(-5), swapped[1].DeltaSeconds)
+}
+
+func TestSummarize_MaxDeltaIsMagnitude(t *testing.T) {
+	item := model.AdjacencyItem{StudentA: "s1", StudentB: "s2", Score: 0.9, Reason: &model.Reason{Evidence: []model.QuestionEvidence{{
+		QuestionID: "q1",
+		Score:      0.9,
+		MatchingRevisions: []model.RevisionMatch{
+			{Ans: "Option B", DeltaSeconds: 4},
+			{Ans: "Option C", DeltaSeconds: -12},
+		},
+	}}}}
+	assert.Contains(t, scoring.Summarize(item), "2 matching revisions within 12s")
+}
+
+func TestAnswerSimilarity_AnswerKey(t *testing.T) {
+	scorer, ok := scoring.Lookup(scoring.AnswerSimilarityName)
+	assert.True(t, ok)
+
+	q := model.Question{QuestionID: "q1", CorrectAnswer: "Option C"}
+	sharedCorrect := scoring.Input{
+		Question: q,
+		A:        []model.AnswerRevision{{SubmittedAt: 100, Ans: "Option C"}},
+		B:        []model.AnswerRevision{{SubmittedAt: 100, Ans: "Option C"}},
+	}
+	sharedWrong := scoring.Input{
+		Question: q,
+		A:        []model.AnswerRevision{{SubmittedAt: 100, Ans: "Option B"}},
+		B:        []model.AnswerRevision{{SubmittedAt: 100, Ans: "Option B"}},
+	}
+	assert.InDelta(t, 1.0, scorer.Score(sharedWrong), 1e-9)
+	assert.Less(t, scorer.Score(sharedCorrect), scorer.Score(sharedWrong))
+
+	configured, err := scorer.(scoring.Configurable).Configure(map[string]float64{"correct_match_weight": 0})
+	assert.Nil(t, err)
+	assert.Equal(t, 0.0, configured.Score(sharedCorrect))
+
+	_, err = scorer.(scoring.Configurable).Configure(map[string]float64{"correct_match_weight": 2})
+	assert.NotNil(t, err)
+}
+
+func TestAnswerRarity_CohortWeighting(t *testing.T) {
+	answers := map[string]map[string][]model.AnswerRevision{}
+	for i, ans := range []string{"A", "A", "A", "A", "A", "A", "A", "A", "D", "D"} {
+		answers[string(rune('a'+i))] = map[string][]model.AnswerRevision{
+			"q1": {{SubmittedAt: int64(100 + i), Ans: ans}},
+		}
+	}
+	cohort := scoring.NewCohort(answers)
+	assert.Equal(t, 10, cohort.Answered("q1"))
+	assert.InDelta(t, 0.8, cohort.AnswerFrequency("q1", "A"), 1e-9)
+
+	scorer, ok := scoring.Lookup(scoring.AnswerRarityName)
+	assert.True(t, ok)
+
+	common := scorer.Score(scoring.Input{QuestionID: "q1", A: answers["a"]["q1"], B: answers["b"]["q1"], Cohort: cohort})
+	rare := scorer.Score(scoring.Input{QuestionID: "q1", A: answers["i"]["q1"], B: answers["j"]["q1"], Cohort: cohort})
+	different := scorer.Score(scoring.Input{QuestionID: "q1", A: answers["a"]["q1"], B: answers["j"]["q1"], Cohort: cohort})
+
+	assert.InDelta(t, 1.0, rare, 1e-9)
+	assert.Less(t, common, 0.2)
+	assert.Equal(t, 0.0, different)
+}
+
+func TestCohort_Update(t *testing.T) {
+	answers := map[string]map[string][]model.AnswerRevision{
+		"s1": {"q1": {{SubmittedAt: 100, Ans: "A"}}},
+		"s2": {"q1": {{SubmittedAt: 110, Ans: "B"}}},
+	}
+	cohort := scoring.NewCohort(answers)
+
+	// s2 changes to A and s3 answers for the first time
+	changed := append(answers["s2"]["q1"], model.AnswerRevision{SubmittedAt: 150, Ans: "A"})
+	cohort.Update("q1", answers["s2"]["q1"], changed)
+	answers["s2"]["q1"] = changed
+	cohort.AddStudent()
+	cohort.Update("q1", nil, []model.AnswerRevision{{SubmittedAt: 160, Ans: "C"}})
+	answers["s3"] = map[string][]model.AnswerRevision{"q1": {{SubmittedAt: 160, Ans: "C"}}}
+
+	assert.Equal(t, scoring.NewCohort(answers), cohort)
+	assert.Equal(t, 3, cohort.Answered("q1"))
+	assert.InDelta(t, 2.0/3, cohort.AnswerFrequency("q1", "A"), 1e-9)
+	assert.Equal(t, 0.0, cohort.AnswerFrequency("q1", "B"))
+}
+
+func TestPValue(t *testing.T) {
+	null := []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9}
+	assert.InDelta(t, 0.1, scoring.PValue(null, 0.95), 1e-9)
+	assert.InDelta(t, 0.2, scoring.PValue(null, 0.9), 1e-9)
+	assert.InDelta(t, 1.0, scoring.PValue(null, 0.0), 1e-9)
+}
+
+func TestBenjaminiHochberg(t *testing.T) {
+	q := scoring.BenjaminiHochberg([]float64{0.04, 0.01, 0.03, 0.5})
+	assert.InDeltaSlice(t, []float64{0.04 * 4 / 3, 0.04, 0.04 * 4 / 3, 0.5}, q, 1e-9)
+}
+
+func TestPermutations_ScaleWithTests(t *testing.T) {
+	cfg := model.SignificanceConfig{Permutations: 1000, FDR: 0.05}
+
+	n, err := scoring.Permutations(cfg, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1000, n)
+
+	// a pair beating every null pair must survive Benjamini-Hochberg over all the tests
+	for _, tests := range []int{4950, 19900, 1999000} {
+		n, err = scoring.Permutations(cfg, tests)
+		assert.Nil(t, err)
+		assert.Less(t, float64(tests)/float64(n+1), cfg.FDR)
+	}
+
+	cfg.MaxPermutations = 50000
+	_, err = scoring.Permutations(cfg, 19900)
+	assert.NotNil(t, err)
+}
+
+func TestValidateSignificance(t *testing.T) {
+	assert.Nil(t, scoring.ValidateSignificance(model.SignificanceConfig{Permutations: 1000, MaxPermutations: 2000000, FDR: 0.05}))
+	assert.NotNil(t, scoring.ValidateSignificance(model.SignificanceConfig{Permutations: -1}))
+	assert.NotNil(t, scoring.ValidateSignificance(model.SignificanceConfig{FDR: 1}))
+	assert.NotNil(t, scoring.ValidateSignificance(model.SignificanceConfig{Permutations: 1000, MaxPermutations: 100}))
+
+	assert.NotNil(t, scoring.ValidateSignificanceCombiner(model.AggregationConfig{}))
+	assert.NotNil(t, scoring.ValidateSignificanceCombiner(model.AggregationConfig{Combiner: scoring.CombinerMax}))
+	assert.Nil(t, scoring.ValidateSignificanceCombiner(model.AggregationConfig{Combiner: scoring.CombinerMean}))
+}
+
+func TestNullDistribution_Deterministic(t *testing.T) {
+	answers := map[string]map[string][]model.AnswerRevision{
+		"s1": {"q1": {{SubmittedAt: 100, Ans: "A"}}},
+		"s2": {"q1": {{SubmittedAt: 200, Ans: "B"}}},
+		"s3": {"q1": {{SubmittedAt: 300, Ans: "C"}}},
+	}
+	cfg := model.SignificanceConfig{Permutations: 50, Seed: 7}
+	score := func(a, b map[string][]model.AnswerRevision) float64 {
+		if a["q1"][0].Ans == b["q1"][0].Ans {
+			return 1
+		}
+		return 0
+	}
+
+	first, err := scoring.NullDistribution(context.Background(), cfg, answers, []string{"s1", "s2", "s3"}, 1, score)
+	assert.Nil(t, err)
+	second, err := scoring.NullDistribution(context.Background(), cfg, answers, []string{"s1", "s2", "s3"}, 1, score)
+	assert.Nil(t, err)
+	assert.Len(t, first, 50)
+	assert.Equal(t, first, second)
+	// donors for the two sides are always different students , so the synthetic pair never matches
+	assert.Equal(t, 0.0, first[len(first)-1])
+}
+
+func TestNullDistribution_SameForAnyWorkerCount(t *testing.T) {
+	answers := map[string]map[string][]model.AnswerRevision{
+		"s1": {"q1": {{SubmittedAt: 100, Ans: "A"}}},
+		"s2": {"q1": {{SubmittedAt: 200, Ans: "B"}}},
+		"s3": {"q1": {{SubmittedAt: 300, Ans: "C"}}},
+		"s4": {"q1": {{SubmittedAt: 450, Ans: "D"}}},
+	}
+	cfg := model.SignificanceConfig{Permutations: 1000, Seed: 3}
+	score := func(a, b map[string][]model.AnswerRevision) float64 {
+		return float64(a["q1"][0].SubmittedAt-b["q1"][0].SubmittedAt) / 1000
+	}
+	pool := []string{"s1", "s2", "s3", "s4"}
+
+	sequential, err := scoring.NullDistribution(context.Background(), cfg, answers, pool, 1, score)
+	assert.Nil(t, err)
+	parallel, err := scoring.NullDistribution(context.Background(), cfg, answers, pool, 8, score)
+	assert.Nil(t, err)
+	assert.Len(t, sequential, 1000)
+	assert.Equal(t, sequential, parallel)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = scoring.NullDistribution(ctx, cfg, answers, pool, 4, score)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestTextComparators(t *testing.T) {
+	assert.Equal(t, "explain goroutines in go", scoring.NormalizeText("  Explain,   GOROUTINES in Go! "))
+	assert.Equal(t, "explain, goroutines in go!", scoring.Normalize("  Explain,   GOROUTINES in Go! "))
+
+	assert.Equal(t, 0.0, scoring.ExactMatch("Option A", "Option B"))
+	assert.Equal(t, 1.0, scoring.ExactMatch("option a", " Option  A"))
+	// punctuation and symbols change the meaning of short answers
+	assert.Equal(t, 0.0, scoring.ExactMatch("3.14", "314"))
+	assert.Equal(t, 0.0, scoring.ExactMatch("-1", "1"))
+	assert.Equal(t, 0.0, scoring.ExactMatch("C++", "C"))
+	assert.False(t, scoring.IsCorrect(model.Question{CorrectAnswer: "C++"}, "C"))
+	assert.True(t, scoring.IsCorrect(model.Question{CorrectAnswer: "C++"}, " c++ "))
+
+	assert.InDelta(t, 1-1.0/6, scoring.LevenshteinSimilarity("kitten", "sitten"), 1e-9)
+	assert.InDelta(t, 0.75, scoring.TokenJaccard("goroutines are cheap", "goroutines are threads cheap"), 1e-9)
+
+	essayA := "Goroutines are lightweight threads managed by the Go runtime."
+	essayB := "Goroutines are lightweight threads that the Go runtime manages."
+	unrelated := "A channel lets one goroutine send typed values to another one."
+	assert.Greater(t, scoring.TextSimilarity(essayA, essayB), 0.6)
+	assert.Less(t, scoring.TextSimilarity(essayA, unrelated), 0.3)
+
+	mcq := scoring.ComparatorFor(model.Question{Type: model.QuestionTypeMCQ})
+	assert.Equal(t, 0.0, mcq("Option A", "Option B"))
+	freeText := scoring.ComparatorFor(model.Question{Type: model.QuestionTypeFreeText})
+	assert.Greater(t, freeText(essayA, essayB), 0.6)
+}
+
+func TestComparatorFor_QuestionTypes(t *testing.T) {
+	multi := scoring.ComparatorFor(model.Question{Type: model.QuestionTypeMultiSelect})
+	assert.Equal(t, 1.0, multi("Option A,Option C", "option c, option a"))
+	assert.InDelta(t, 1.0/3, multi("Option A,Option C", "Option A,Option B"), 1e-9)
+
+	numeric := scoring.ComparatorFor(model.Question{Type: model.QuestionTypeNumeric, Metadata: model.QuestionMetadata{Tolerance: 0.01}})
+	assert.Equal(t, 1.0, numeric("3.14", "3.141"))
+	assert.Equal(t, 0.0, numeric("3.14", "3.2"))
+
+	code := scoring.ComparatorFor(model.Question{Type: model.QuestionTypeCode})
+	assert.Equal(t, 1.0, code("go  work()\n", "go work()"))
+	assert.Less(t, code("go work()", "Go Work()"), 1.0)
+
+	key := model.Question{Type: model.QuestionTypeMultiSelect, CorrectAnswer: "Option A,Option C"}
+	assert.True(t, scoring.IsCorrect(key, "Option C,Option A"))
+	assert.False(t, scoring.IsCorrect(key, "Option C"))
+}
+
+func TestTimelineLag_FollowerEditsAfterLeader(t *testing.T) {
+	a := map[string][]model.AnswerRevision{
+		"q1": {{SubmittedAt: 100, Ans: "B"}, {SubmittedAt: 160, Ans: "C"}},
+		"q2": {{SubmittedAt: 300, Ans: "A"}},
+		"q3": {{SubmittedAt: 500, Ans: "D"}},
+	}
+	b := map[string][]model.AnswerRevision{
+		"q1": {{SubmittedAt: 112, Ans: "B"}, {SubmittedAt: 171, Ans: "C"}},
+		"q2": {{SubmittedAt: 313, Ans: "A"}},
+		"q3": {{SubmittedAt: 512, Ans: "D"}},
+	}
+
+	lag := scoring.TimelineLag(a, b, model.LagConfig{})
+	assert.Equal(t, scoring.LeaderA, lag.Leader())
+	assert.InDelta(t, 12, lag.LagSeconds, 1)
+	assert.Equal(t, 1.0, lag.Strength)
+
+	reverse := scoring.TimelineLag(b, a, model.LagConfig{})
+	assert.Equal(t, scoring.LeaderB, reverse.Leader())
+
+	assert.Equal(t, "", scoring.TimelineLag(a, map[string][]model.AnswerRevision{}, model.LagConfig{}).Leader())
+}
+
+func TestTimeCorrelation_UnevenRevisionCounts(t *testing.T) {
+	scorer, ok := scoring.Lookup(scoring.TimeCorrelationName)
+	assert.True(t, ok)
+
+	in := scoring.Input{
+		A: []model.AnswerRevision{{SubmittedAt: 100}, {SubmittedAt: 110}, {SubmittedAt: 120}},
+		B: []model.AnswerRevision{{SubmittedAt: 100}, {SubmittedAt: 110}},
+	}
+	assert.InDelta(t, 1.0, scorer.Score(in), 1e-9)
+
+	far := scoring.Input{
+		A: []model.AnswerRevision{{SubmittedAt: 100}},
+		B: []model.AnswerRevision{{SubmittedAt: 400}},
+	}
+	assert.Equal(t, 0.0, scorer.Score(far))
+}
+
+func TestTimeCorrelation_ScoresConsistencyNotLagSize(t *testing.T) {
+	scorer, ok := scoring.Lookup(scoring.TimeCorrelationName)
+	assert.True(t, ok)
+	following := func(lag int64) scoring.Input {
+		return scoring.Input{
+			A: []model.AnswerRevision{{SubmittedAt: 100}, {SubmittedAt: 200}},
+			B: []model.AnswerRevision{{SubmittedAt: 100 + lag}, {SubmittedAt: 200 + lag}},
+		}
+	}
+
+	// a copier half a minute behind is as consistent as one two seconds behind
+	assert.InDelta(t, 1.0, scorer.Score(following(2)), 1e-9)
+	assert.InDelta(t, 1.0, scorer.Score(following(30)), 1e-9)
+
+	// a single coincidence is not a consistent lag yet
+	lone := scoring.Input{A: []model.AnswerRevision{{SubmittedAt: 100}}, B: []model.AnswerRevision{{SubmittedAt: 125}}}
+	assert.InDelta(t, 0.5, scorer.Score(lone), 1e-9)
+
+	// revisions following at different delays are not
+	drifting := scoring.Input{
+		A: []model.AnswerRevision{{SubmittedAt: 100}, {SubmittedAt: 200}},
+		B: []model.AnswerRevision{{SubmittedAt: 105}, {SubmittedAt: 240}},
+	}
+	assert.InDelta(t, 0.25, scorer.Score(drifting), 1e-9)
+}
+
+func TestNewPipeline_LagFromScoringConfig(t *testing.T) {
+	exact := int64(0)
+	in := scoring.Input{
+		A: []model.AnswerRevision{{SubmittedAt: 100}, {SubmittedAt: 200}},
+		B: []model.AnswerRevision{{SubmittedAt: 110}, {SubmittedAt: 211}},
+	}
+	scorers := []model.ScorerConfig{{Name: scoring.TimeCorrelationName, Weight: 1, Enabled: true}}
+
+	// the default two seconds of tolerance puts both revisions on one lag
+	p, err := scoring.NewPipeline(model.ScoringConfig{Scorers: scorers})
+	assert.Nil(t, err)
+	assert.InDelta(t, 1.0, p.Score(in), 1e-9)
+
+	// a tolerance of zero is honored rather than replaced by the default
+	p, err = scoring.NewPipeline(model.ScoringConfig{Scorers: scorers, Lag: model.LagConfig{ToleranceSeconds: &exact}})
+	assert.Nil(t, err)
+	assert.InDelta(t, 0.25, p.Score(in), 1e-9)
+
+	// the lag window has one home , scorer params for it are refused
+	_, err = scoring.NewPipeline(model.ScoringConfig{Scorers: []model.ScorerConfig{
+		{Name: scoring.TimeCorrelationName, Weight: 1, Enabled: true, Params: map[string]float64{"tolerance_seconds": 0}},
+	}})
+	assert.ErrorContains(t, err, "scoring.lag")
+
+	negative := int64(-1)
+	_, err = scoring.NewPipeline(model.ScoringConfig{Scorers: scorers, Lag: model.LagConfig{ToleranceSeconds: &negative}})
+	assert.NotNil(t, err)
+}
+
+func TestAttribute_CopierConvergesOnSource(t *testing.T) {
+	source := map[string][]model.AnswerRevision{
+		"q1": {{SubmittedAt: 100, Ans: "Option B"}, {SubmittedAt: 140, Ans: "Option C"}},
+		"q2": {{SubmittedAt: 200, Ans: "Option D"}},
+	}
+	copier := map[string][]model.AnswerRevision{
+		"q1": {{SubmittedAt: 90, Ans: "Option A"}, {SubmittedAt: 110, Ans: "Option B"}, {SubmittedAt: 150, Ans: "Option C"}},
+		"q2": {{SubmittedAt: 215, Ans: "Option D"}},
+	}
+
+	att := scoring.Attribute(source, copier, []string{"q1", "q2"}, scoring.Lag{})
+	assert.Equal(t, scoring.LeaderA, att.Source)
+	assert.Equal(t, 2, att.FirstCommit[0])
+	assert.Equal(t, 3, att.Convergence[0])
+	assert.Equal(t, 1.0, att.Confidence)
+
+	reverse := scoring.Attribute(copier, source, []string{"q1", "q2"}, scoring.Lag{})
+	assert.Equal(t, scoring.LeaderB, reverse.Source)
+
+	simultaneous := map[string][]model.AnswerRevision{"q1": {{SubmittedAt: 100, Ans: "Option B"}}}
+	none := scoring.Attribute(simultaneous, simultaneous, []string{"q1"}, scoring.Lag{})
+	assert.Equal(t, "", none.Source)
+}
+
+func TestCompareResponseVectors(t *testing.T) {
+	questions := []model.Question{
+		{QuestionID: "q1", CorrectAnswer: "A"},
+		{QuestionID: "q2", CorrectAnswer: "B"},
+		{QuestionID: "q3", CorrectAnswer: "C"},
+		{QuestionID: "q4", CorrectAnswer: "D"},
+	}
+	a := map[string][]model.AnswerRevision{
+		"q1": {{SubmittedAt: 10, Ans: "A"}},
+		"q2": {{SubmittedAt: 20, Ans: "C"}},
+		"q3": {{SubmittedAt: 30, Ans: "D"}},
+		"q4": {{SubmittedAt: 40, Ans: "A"}},
+	}
+	b := map[string][]model.AnswerRevision{
+		"q1": {{SubmittedAt: 12, Ans: "A"}},
+		"q2": {{SubmittedAt: 22, Ans: "C"}},
+		"q3": {{SubmittedAt: 32, Ans: "D"}},
+		"q4": {{SubmittedAt: 42, Ans: "B"}},
+	}
+
+	v := scoring.CompareResponseVectors(scoring.ExamInput{Questions: questions, A: a, B: b})
+	assert.Equal(t, 3, v.LongestStreak)
+	assert.InDelta(t, 0.75, v.StreakShare, 1e-9)
+	assert.InDelta(t, 2.0/3, v.SharedIncorrect, 1e-9)
+	assert.InDelta(t, 1.0, v.OrderSimilarity, 1e-9)
+
+	p, err := scoring.NewPipeline(model.ScoringConfig{Scorers: []model.ScorerConfig{
+		{Name: scoring.AnswerSimilarityName, Weight: 1, Enabled: true},
+		{Name: scoring.ResponseVectorName, Weight: 1, Enabled: true},
+	}})
+	assert.Nil(t, err)
+	assert.True(t, p.HasExamScorers())
+	exam := p.EvaluateExam(scoring.ExamInput{Questions: questions, A: a, B: b})
+	assert.Contains(t, exam.SubScores, scoring.ResponseVectorName)
+	assert.Greater(t, exam.Score, 0.0)
+}
+
+func TestKendallTau(t *testing.T) {
+	assert.InDelta(t, 1.0, scoring.KendallTau([]string{"q1", "q2", "q3"}, []string{"q1", "q2", "q3"}), 1e-9)
+	assert.InDelta(t, -1.0, scoring.KendallTau([]string{"q1", "q2", "q3"}, []string{"q3", "q2", "q1"}), 1e-9)
+	assert.InDelta(t, 1.0/3, scoring.KendallTau([]string{"q1", "q2", "q3"}, []string{"q2", "q1", "q3"}), 1e-9)
+	assert.Equal(t, 0.0, scoring.KendallTau([]string{"q1"}, []string{"q1"}))
+}
+
+func TestNavigationOrder_LockstepJumps(t *testing.T) {
+	questions := []model.Question{{QuestionID: "q1"}, {QuestionID: "q2"}, {QuestionID: "q3"}, {QuestionID: "q4"}, {QuestionID: "q5"}}
+	jump := func(offset int64) map[string][]model.AnswerRevision {
+		return map[string][]model.AnswerRevision{
+			"q5": {{SubmittedAt: offset + 10, Ans: "A"}},
+			"q2": {{SubmittedAt: offset + 20, Ans: "B"}, {SubmittedAt: offset + 60, Ans: "C"}},
+			"q4": {{SubmittedAt: offset + 30, Ans: "D"}},
+			"q1": {{SubmittedAt: offset + 40, Ans: "A"}},
+			"q3": {{SubmittedAt: offset + 50, Ans: "B"}},
+		}
+	}
+	inOrder := map[string][]model.AnswerRevision{
+		"q1": {{SubmittedAt: 10, Ans: "A"}},
+		"q2": {{SubmittedAt: 20, Ans: "B"}},
+		"q3": {{SubmittedAt: 30, Ans: "C"}},
+		"q4": {{SubmittedAt: 40, Ans: "D"}},
+		"q5": {{SubmittedAt: 50, Ans: "A"}},
+	}
+
+	nav := scoring.BuildNavigation(jump(0))
+	assert.Equal(t, []string{"q5", "q2", "q4", "q1", "q3"}, nav.FirstTouch)
+	assert.Equal(t, []string{"q2"}, nav.Revisits)
+
+	scorer, ok := scoring.LookupExam(scoring.NavigationOrderName)
+	assert.True(t, ok)
+	lockstep := scorer.ScoreExam(scoring.ExamInput{Questions: questions, A: jump(0), B: jump(3)})
+	frontToBack := scorer.ScoreExam(scoring.ExamInput{Questions: questions, A: inOrder, B: inOrder})
+	assert.Greater(t, lockstep, 0.8)
+	assert.Equal(t, 0.0, frontToBack)
+}
+
+func TestCompareSkips(t *testing.T) {
+	questions := []model.Question{{QuestionID: "q1"}, {QuestionID: "q2"}, {QuestionID: "q3"}, {QuestionID: "q4"}}
+	a := map[string][]model.AnswerRevision{
+		"q1": {{SubmittedAt: 10, Ans: "A"}},
+		"q3": {{SubmittedAt: 30, Ans: "C"}},
+	}
+	b := map[string][]model.AnswerRevision{
+		"q1": {{SubmittedAt: 12, Ans: "A"}},
+		"q3": {},
+	}
+
+	c := scoring.CompareSkips(scoring.ExamInput{Questions: questions, A: a, B: b})
+	assert.Equal(t, []string{"q2", "q4"}, c.SkippedA)
+	assert.Equal(t, []string{"q2", "q3", "q4"}, c.SkippedB)
+	assert.Equal(t, 2, c.Shared)
+	assert.InDelta(t, 2.0/3, c.Jaccard, 1e-9)
+
+	scorer, ok := scoring.LookupExam(scoring.SkipPatternName)
+	assert.True(t, ok)
+	assert.InDelta(t, 2.0/3, scorer.ScoreExam(scoring.ExamInput{Questions: questions, A: a, B: b}), 1e-9)
+	// nothing skipped , or no exam definition to know what was skipped , is no signal
+	assert.Equal(t, 0.0, scorer.ScoreExam(scoring.ExamInput{Questions: questions[:1], A: a, B: b}))
+	assert.Equal(t, 0.0, scorer.ScoreExam(scoring.ExamInput{A: a, B: b}))
+}
+
+func TestCompareSkips_WeightsByCohortSkipRate(t *testing.T) {
+	questions := []model.Question{{QuestionID: "q1"}, {QuestionID: "q2"}, {QuestionID: "q3"}}
+	a := map[string][]model.AnswerRevision{"q1": {{SubmittedAt: 10, Ans: "A"}}}
+	b := map[string][]model.AnswerRevision{"q1": {{SubmittedAt: 12, Ans: "A"}}}
+	table := map[string]map[string][]model.AnswerRevision{"a": a, "b": b}
+	// q2 is left blank by the whole class , q3 only by the pair
+	for i := 0; i < 8; i++ {
+		table[fmt.Sprintf("s%d", i)] = map[string][]model.AnswerRevision{
+			"q1": {{SubmittedAt: 10, Ans: "A"}},
+			"q3": {{SubmittedAt: 30, Ans: "C"}},
+		}
+	}
+	cohort := scoring.NewCohort(table)
+	assert.Equal(t, 1.0, cohort.SkipFrequency("q2"))
+	assert.InDelta(t, 0.2, cohort.SkipFrequency("q3"), 1e-9)
+	assert.Equal(t, 0.0, cohort.SkipRarity("q2"))
+	assert.Equal(t, 1.0, cohort.SkipRarity("q3"))
+
+	scorer, ok := scoring.LookupExam(scoring.SkipPatternName)
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, scorer.ScoreExam(scoring.ExamInput{Questions: questions, A: a, B: b, Cohort: cohort}))
+	// sharing only the skip everyone made is no signal
+	assert.Equal(t, 0.0, scorer.ScoreExam(scoring.ExamInput{Questions: questions[:2], A: a, B: b, Cohort: cohort}))
+
+	// a pair splitting on the rare skip scores low even though they share the common one
+	c := scoring.CompareSkips(scoring.ExamInput{Questions: questions, A: a, B: table["s0"], Cohort: cohort})
+	assert.Equal(t, 1, c.Shared)
+	assert.InDelta(t, 0.5, c.Jaccard, 1e-9)
+	assert.Equal(t, 0.0, c.Weighted)
+}
+
+func TestPipeline_SkippedQuestion(t *testing.T) {
+	var scorers []model.ScorerConfig
+	for _, name := range []string{scoring.AnswerSimilarityName, scoring.TimeCorrelationName, scoring.EditPatternName, scoring.AnswerRarityName} {
+		scorers = append(scorers, model.ScorerConfig{Name: name, Weight: 1, Enabled: true})
+	}
+	p, err := scoring.NewPipeline(model.ScoringConfig{Scorers: scorers})
+	assert.Nil(t, err)
+
+	answered := []model.AnswerRevision{{SubmittedAt: 10, Ans: "A"}}
+	cohort := scoring.NewCohort(map[string]map[string][]model.AnswerRevision{"s1": {"q1": answered}, "s2": {}})
+	for _, in := range []scoring.Input{
+		{QuestionID: "q1", A: answered, Cohort: cohort},
+		{QuestionID: "q1", B: answered, Cohort: cohort},
+		{QuestionID: "q1", A: []model.AnswerRevision{}, B: nil, Cohort: cohort},
+	} {
+		res := p.Evaluate(in)
+		assert.Equal(t, 0.0, res.Score)
+		assert.Empty(t, scoring.Explain(in, res).MatchingRevisions)
+	}
+}
+
+func TestEditPattern_UnevenRevisionCounts(t *testing.T) {
+	revisions := func(answers ...string) []model.AnswerRevision {
+		out := make([]model.AnswerRevision, 0, len(answers))
+		for i, ans := range answers {
+			out = append(out, model.AnswerRevision{SubmittedAt: int64(10 * (i + 1)), Ans: ans})
+		}
+		return out
+	}
+	scorer, ok := scoring.Lookup(scoring.EditPatternName)
+	assert.True(t, ok)
+
+	for _, tc := range []struct {
+		name string
+		a, b []model.AnswerRevision
+		want float64
+	}{
+		{"longer A", revisions("A", "B", "C"), revisions("B", "C"), 1},
+		{"longer B", revisions("C"), revisions("A", "B", "C"), 1},
+		{"longer A diverging", revisions("B", "C", "D"), revisions("B", "C"), 0},
+		{"half matching tail", revisions("A", "B", "C", "D"), revisions("X", "D"), 0.5},
+		{"empty A", nil, revisions("A", "B"), 0},
+		{"empty B", revisions("A"), []model.AnswerRevision{}, 0},
+		{"both empty", nil, nil, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.InDelta(t, tc.want, scorer.Score(scoring.Input{QuestionID: "q1", A: tc.a, B: tc.b}), 1e-9)
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package scoring
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+)
+
+const shingleSize = 3
+
+// Comparator returns how similar two answers are in the range [0,1]
+type Comparator func(a, b string) float64
+
+// ComparatorFor picks the answer comparator for the type of q . Free text answers are compared with a
+// blend of edit distance , token overlap and character shingles , code keeps its punctuation and case ,
+// multi select answers are compared as option sets and numeric answers within the question tolerance .
+// MCQ and untyped questions must match up to case and whitespace
+func ComparatorFor(q model.Question) Comparator {
+	switch q.Type {
+	case model.QuestionTypeFreeText:
+		return TextSimilarity
+	case model.QuestionTypeCode:
+		return CodeSimilarity
+	case model.QuestionTypeMultiSelect:
+		return SelectionOverlap
+	case model.QuestionTypeNumeric:
+		return numericMatch(q.Metadata.Tolerance)
+	default:
+		return ExactMatch
+	}
+}
+
+// SplitSelections returns the normalized options of a multi select answer
+func SplitSelections(ans string) []string {
+	var out []string
+	for _, part := range strings.Split(ans, model.MultiSelectSeparator) {
+		if n := Normalize(part); n != "" {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// SelectionOverlap is the Jaccard index of the options chosen in two multi select answers
+func SelectionOverlap(a, b string) float64 {
+	setA := make(map[string]struct{})
+	for _, s := range SplitSelections(a) {
+		setA[s] = struct{}{}
+	}
+	setB := make(map[string]struct{})
+	for _, s := range SplitSelections(b) {
+		setB[s] = struct{}{}
+	}
+	return jaccard(setA, setB)
+}
+
+// CodeSimilarity compares two code answers after collapsing whitespace , keeping case and punctuation
+// since they carry meaning in code
+func CodeSimilarity(a, b string) float64 {
+	na, nb := strings.Join(strings.Fields(a), " "), strings.Join(strings.Fields(b), " ")
+	if na == "" || nb == "" {
+		return 0
+	}
+	return (levenshteinSimilarity(na, nb) + jaccard(shingles(na), shingles(nb))) / 2
+}
+
+func numericMatch(tolerance float64) Comparator {
+	return func(a, b string) float64 {
+		x, errA := strconv.ParseFloat(strings.TrimSpace(a), 64)
+		y, errB := strconv.ParseFloat(strings.TrimSpace(b), 64)
+		if errA != nil || errB != nil {
+			return ExactMatch(a, b)
+		}
+		if math.Abs(x-y) <= tolerance {
+			return 1
+		}
+		return 0
+	}
+}
+
+// Normalize lower-cases s and collapses runs of whitespace , keeping punctuation and symbols since "3.14" , "-1"
+// and "C++" are different answers from "314" , "1" and "C" . Exact comparisons and answer keys go through it
+func Normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// NormalizeText lower-cases s , drops punctuation and collapses runs of whitespace . Only the free text token
+// metrics use it , where a missing comma should not count as a different word
+func NormalizeText(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteRune(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		case unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r):
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// ExactMatch is 1 when both answers are equal up to case and whitespace
+func ExactMatch(a, b string) float64 {
+	na, nb := Normalize(a), Normalize(b)
+	if na == "" || nb == "" || na != nb {
+		return 0
+	}
+	return 1
+}
+
+// TextSimilarity averages normalized Levenshtein similarity , token Jaccard and shingle overlap
+func TextSimilarity(a, b string) float64 {
+	na, nb := NormalizeText(a), NormalizeText(b)
+	if na == "" || nb == "" {
+		return 0
+	}
+	return (levenshteinSimilarity(na, nb) + jaccard(tokens(na), tokens(nb)) + jaccard(shingles(na), shingles(nb))) / 3
+}
+
+// LevenshteinSimilarity is one minus the edit distance divided by the longer normalized answer
+func LevenshteinSimilarity(a, b string) float64 {
+	na, nb := NormalizeText(a), NormalizeText(b)
+	if na == "" || nb == "" {
+		return 0
+	}
+	return levenshteinSimilarity(na, nb)
+}
+
+// TokenJaccard is the Jaccard index of the word sets of both normalized answers
+func TokenJaccard(a, b string) float64 {
+	return jaccard(tokens(NormalizeText(a)), tokens(NormalizeText(b)))
+}
+
+// ShingleOverlap is the Jaccard index of the character n-grams of both normalized answers
+func ShingleOverlap(a, b string) float64 {
+	return jaccard(shingles(NormalizeText(a)), shingles(NormalizeText(b)))
+}
+
+func levenshteinSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	longest := max(len(ra), len(rb))
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
+
+func tokens(s string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, t := range strings.Fields(s) {
+		set[t] = struct{}{}
+	}
+	return set
+}
+
+func shingles(s string) map[string]struct{} {
+	set := make(map[string]struct{})
+	r := []rune(s)
+	if len(r) < shingleSize {
+		if len(r) > 0 {
+			set[s] = struct{}{}
+		}
+		return set
+	}
+	for i := 0; i+shingleSize <= len(r); i++ {
+		set[string(r[i:i+shingleSize])] = struct{}{}
+	}
+	return set
+}
+
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	var inter int
+	for k := range a {
+		if _, ok := b[k]; ok {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(a)+len(b)-inter)
+}
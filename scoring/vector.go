@@ -0,0 +1,140 @@
+package scoring
+
+import (
+	"sort"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+)
+
+const ResponseVectorName = "response_vector"
+
+func init() {
+	RegisterExam(responseVectorScorer{})
+}
+
+// VectorComparison compares the final answers of two students as vectors over the whole exam
+type VectorComparison struct {
+	// LongestStreak is the longest run of consecutive questions with identical final answers
+	LongestStreak int
+	// StreakShare is LongestStreak over the number of questions in the exam
+	StreakShare float64
+	// SharedIncorrect is the share of questions both got wrong where they gave the same wrong answer
+	SharedIncorrect float64
+	// OrderSimilarity compares the order in which both students settled their final answers
+	OrderSimilarity float64
+}
+
+// responseVectorScorer averages the streak share , shared incorrect share and answer order similarity
+type responseVectorScorer struct{}
+
+func (responseVectorScorer) Name() string { return ResponseVectorName }
+
+func (responseVectorScorer) ScoreExam(in ExamInput) float64 {
+	v := CompareResponseVectors(in)
+	return (v.StreakShare + v.SharedIncorrect + v.OrderSimilarity) / 3
+}
+
+// CompareResponseVectors treats the final answers of each student as a vector in exam question order
+func CompareResponseVectors(in ExamInput) VectorComparison {
+	questions := ExamOrder(in)
+	if len(questions) == 0 {
+		return VectorComparison{}
+	}
+
+	var v VectorComparison
+	var streak, bothWrong, sameWrong int
+	for _, q := range questions {
+		aRevisions, bRevisions := in.A[q.QuestionID], in.B[q.QuestionID]
+		if len(aRevisions) == 0 || len(bRevisions) == 0 {
+			streak = 0
+			continue
+		}
+		finalA, finalB := aRevisions[len(aRevisions)-1].Ans, bRevisions[len(bRevisions)-1].Ans
+		same := ComparatorFor(q)(finalA, finalB) == 1
+		if same {
+			streak++
+			v.LongestStreak = max(v.LongestStreak, streak)
+		} else {
+			streak = 0
+		}
+
+		if q.CorrectAnswer != "" && !IsCorrect(q, finalA) && !IsCorrect(q, finalB) {
+			bothWrong++
+			if same {
+				sameWrong++
+			}
+		}
+	}
+
+	v.StreakShare = float64(v.LongestStreak) / float64(len(questions))
+	if bothWrong > 0 {
+		v.SharedIncorrect = float64(sameWrong) / float64(bothWrong)
+	}
+	v.OrderSimilarity = sequenceSimilarity(settleOrder(in.A), settleOrder(in.B))
+	return v
+}
+
+// ExamOrder returns the exam questions in presentation order . When the exam definition is unavailable the
+// questions answered by either student are used in id order
+func ExamOrder(in ExamInput) []model.Question {
+	if len(in.Questions) > 0 {
+		return in.Questions
+	}
+	seen := make(map[string]bool)
+	var questions []model.Question
+	for _, answers := range []map[string][]model.AnswerRevision{in.A, in.B} {
+		for qID := range answers {
+			if !seen[qID] {
+				seen[qID] = true
+				questions = append(questions, model.Question{QuestionID: qID})
+			}
+		}
+	}
+	sort.Slice(questions, func(i, j int) bool { return questions[i].QuestionID < questions[j].QuestionID })
+	return questions
+}
+
+// settleOrder lists question ids in the order their final answers were entered
+func settleOrder(answers map[string][]model.AnswerRevision) []string {
+	type settled struct {
+		qID string
+		at  int64
+	}
+	var order []settled
+	for qID, revisions := range answers {
+		if len(revisions) > 0 {
+			order = append(order, settled{qID: qID, at: settledAt(revisions)})
+		}
+	}
+	sort.Slice(order, func(i, j int) bool {
+		if order[i].at != order[j].at {
+			return order[i].at < order[j].at
+		}
+		return order[i].qID < order[j].qID
+	})
+	out := make([]string, 0, len(order))
+	for _, s := range order {
+		out = append(out, s.qID)
+	}
+	return out
+}
+
+// sequenceSimilarity is the length of the longest common subsequence over the longer sequence
+func sequenceSimilarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			if a[i-1] == b[j-1] {
+				curr[j] = prev[j-1] + 1
+			} else {
+				curr[j] = max(prev[j], curr[j-1])
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return float64(prev[len(b)]) / float64(max(len(a), len(b)))
+}
@@ -14,50 +14,80 @@ post_answer () {
     }" > /dev/null
 }
 
-echo "=== Starting normal MCQ submissions ==="
+echo "=== Starting normal submissions ==="
 
 # ---------- NORMAL STUDENTS ----------
 NORMAL_STUDENTS=(s1 s2 s4 s5 s6 s8 s9 s10)
 OPTIONS=(A B C D)
 
+EXPLANATIONS=(
+  "Goroutines are functions running concurrently with others in the same address space."
+  "A goroutine is a lightweight thread scheduled by the Go runtime rather than the OS."
+  "The go keyword starts a goroutine which runs independently of its caller."
+  "Goroutines start with small stacks that grow as needed so thousands can run at once."
+)
+KEYWORDS=("go,defer" "go,select" "defer,select" "go,defer,select" "go,yield")
+
 random_option () {
   echo "${OPTIONS[$RANDOM % 4]}"
 }
 
+random_explanation () {
+  echo "${EXPLANATIONS[$RANDOM % 4]}"
+}
+
+random_keywords () {
+  echo "${KEYWORDS[$RANDOM % 5]}"
+}
+
 for student in "${NORMAL_STUDENTS[@]}"; do
   post_answer Q1 "Option $(random_option)" "$student"
   sleep 1
 
-  post_answer Q2 "Option $(random_option)" "$student"
+  post_answer Q2 "$(random_explanation)" "$student"
   sleep 1
 
   post_answer Q3 "Option $(random_option)" "$student"
   sleep 1
+
+  post_answer Q4 "$((RANDOM % 2 * 32 + 32))" "$student"
+  sleep 1
+
+  post_answer Q5 "$(random_keywords)" "$student"
+  sleep 1
 done
 
-echo "=== Simulating MCQ copying behavior ==="
+echo "=== Simulating copying behavior ==="
 
 # ---------- CHEATING STUDENTS ----------
 # s3 and s7 copy ONLY Q2
 
-# First attempt (both unsure, pick same wrong option)
-post_answer Q2 "Option B" "s3"
+# First attempt (both paste the same short draft)
+post_answer Q2 "Goroutines are threads." "s3"
 sleep 1
-post_answer Q2 "Option B" "s7"
+post_answer Q2 "Goroutines are threads." "s7"
 
-# Second edit (both switch to correct option almost together)
+# Second edit (both extend the draft the same way almost together)
 sleep 2
-post_answer Q2 "Option C" "s3"
+post_answer Q2 "Goroutines are lightweight threads managed by the Go runtime and talk over channels." "s3"
 sleep 1
-post_answer Q2 "Option C" "s7"
+post_answer Q2 "Goroutines are lightweight threads managed by the Go runtime and talk over channels." "s7"
 
 # Legit answers for other questions
 post_answer Q1 "Option A" "s3"
 sleep 1
 post_answer Q3 "Option D" "s3"
+sleep 1
+post_answer Q4 "64" "s3"
+sleep 1
+post_answer Q5 "go,select" "s3"
 
 post_answer Q1 "Option B" "s7"
 sleep 1
 post_answer Q3 "Option A" "s7"
+sleep 1
+post_answer Q4 "32" "s7"
+sleep 1
+post_answer Q5 "go,defer,select" "s7"
 
-echo "=== MCQ Simulation complete ==="
+echo "=== Simulation complete ==="
@@ -1,18 +1,25 @@
 package contract
 
-import "github.com/hyperledger/fabric-gateway/pkg/client"
+import (
+	"context"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
 
 type Contract interface {
 	SubmitTransaction(name string, args ...string) ([]byte, error)
 	EvaluateTransaction(name string, args ...string) ([]byte, error)
+	// ChaincodeEvents streams the events emitted by the contract's chaincode until ctx is done
+	ChaincodeEvents(ctx context.Context, options ...client.ChaincodeEventsOption) (<-chan *client.ChaincodeEvent, error)
 }
 
 type fabricContract struct {
+	network  *client.Network
 	contract *client.Contract
 }
 
-func NewFabricContract(c *client.Contract) Contract {
-	return &fabricContract{contract: c}
+func NewFabricContract(network *client.Network, chaincodeName string) Contract {
+	return &fabricContract{network: network, contract: network.GetContract(chaincodeName)}
 }
 
 func (f *fabricContract) SubmitTransaction(name string, args ...string) ([]byte, error) {
@@ -22,3 +29,7 @@ func (f *fabricContract) SubmitTransaction(name string, args ...string) ([]byte,
 func (f *fabricContract) EvaluateTransaction(name string, args ...string) ([]byte, error) {
 	return f.contract.EvaluateTransaction(name, args...)
 }
+
+func (f *fabricContract) ChaincodeEvents(ctx context.Context, options ...client.ChaincodeEventsOption) (<-chan *client.ChaincodeEvent, error) {
+	return f.network.ChaincodeEvents(ctx, f.contract.ChaincodeName(), options...)
+}
@@ -93,6 +93,5 @@ var GetFabricGateway = func(id identity.Identity, signer identity.Sign, grpcClie
 }
 
 var GetContract = func(gw *client.Gateway, channelName, chainCodeName string) contract.Contract {
-	c := gw.GetNetwork(channelName).GetContract(chainCodeName)
-	return contract.NewFabricContract(c)
+	return contract.NewFabricContract(gw.GetNetwork(channelName), chainCodeName)
 }
@@ -2,13 +2,56 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	client "github.com/hyperledger/fabric-gateway/pkg/client"
+
+	mock "github.com/stretchr/testify/mock"
+)
 
 // Contract is an autogenerated mock type for the Contract type
 type Contract struct {
 	mock.Mock
 }
 
+// ChaincodeEvents provides a mock function with given fields: ctx, options
+func (_m *Contract) ChaincodeEvents(ctx context.Context, options ...client.ChaincodeEventsOption) (<-chan *client.ChaincodeEvent, error) {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChaincodeEvents")
+	}
+
+	var r0 <-chan *client.ChaincodeEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, ...client.ChaincodeEventsOption) (<-chan *client.ChaincodeEvent, error)); ok {
+		return rf(ctx, options...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, ...client.ChaincodeEventsOption) <-chan *client.ChaincodeEvent); ok {
+		r0 = rf(ctx, options...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan *client.ChaincodeEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, ...client.ChaincodeEventsOption) error); ok {
+		r1 = rf(ctx, options...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // EvaluateTransaction provides a mock function with given fields: name, args
 func (_m *Contract) EvaluateTransaction(name string, args ...string) ([]byte, error) {
 	_va := make([]interface{}, len(args))
@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	model "github.com/deeraj-kumar/exam-audit/domain"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -17,6 +19,92 @@ func (_m *FabricService) Close() {
 	_m.Called()
 }
 
+// ExamEvents provides a mock function with given fields: ctx, startBlock
+func (_m *FabricService) ExamEvents(ctx context.Context, startBlock uint64) (<-chan model.LedgerEvent, error) {
+	ret := _m.Called(ctx, startBlock)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExamEvents")
+	}
+
+	var r0 <-chan model.LedgerEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) (<-chan model.LedgerEvent, error)); ok {
+		return rf(ctx, startBlock)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) <-chan model.LedgerEvent); ok {
+		r0 = rf(ctx, startBlock)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan model.LedgerEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, startBlock)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LedgerHeight provides a mock function with no fields
+func (_m *FabricService) LedgerHeight() (uint64, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for LedgerHeight")
+	}
+
+	var r0 uint64
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (uint64, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() uint64); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MigrateLegacyAnswers provides a mock function with given fields: examID
+func (_m *FabricService) MigrateLegacyAnswers(examID string) (int, error) {
+	ret := _m.Called(examID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MigrateLegacyAnswers")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (int, error)); ok {
+		return rf(examID)
+	}
+	if rf, ok := ret.Get(0).(func(string) int); ok {
+		r0 = rf(examID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(examID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // QueryEdittedAnswersByExam provides a mock function with given fields: exam, students
 func (_m *FabricService) QueryEdittedAnswersByExam(exam model.Exam, students []model.Student) ([]model.Answer, error) {
 	ret := _m.Called(exam, students)
@@ -47,6 +135,98 @@ func (_m *FabricService) QueryEdittedAnswersByExam(exam model.Exam, students []m
 	return r0, r1
 }
 
+// QueryExamSchedule provides a mock function with given fields: examID
+func (_m *FabricService) QueryExamSchedule(examID string) (model.ExamSchedule, error) {
+	ret := _m.Called(examID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for QueryExamSchedule")
+	}
+
+	var r0 model.ExamSchedule
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (model.ExamSchedule, error)); ok {
+		return rf(examID)
+	}
+	if rf, ok := ret.Get(0).(func(string) model.ExamSchedule); ok {
+		r0 = rf(examID)
+	} else {
+		r0 = ret.Get(0).(model.ExamSchedule)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(examID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ScheduleExam provides a mock function with given fields: examID, startTime, endTime
+func (_m *FabricService) ScheduleExam(examID string, startTime int64, endTime int64) error {
+	ret := _m.Called(examID, startTime, endTime)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ScheduleExam")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, int64, int64) error); ok {
+		r0 = rf(examID, startTime, endTime)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetExamState provides a mock function with given fields: examID, state
+func (_m *FabricService) SetExamState(examID string, state string) error {
+	ret := _m.Called(examID, state)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetExamState")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(examID, state)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// QueryExamAnswerHistoryPage provides a mock function with given fields: examID, pageSize, bookmark
+func (_m *FabricService) QueryExamAnswerHistoryPage(examID string, pageSize int32, bookmark string) (model.ExamAnswerHistoryPage, error) {
+	ret := _m.Called(examID, pageSize, bookmark)
+
+	if len(ret) == 0 {
+		panic("no return value specified for QueryExamAnswerHistoryPage")
+	}
+
+	var r0 model.ExamAnswerHistoryPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, int32, string) (model.ExamAnswerHistoryPage, error)); ok {
+		return rf(examID, pageSize, bookmark)
+	}
+	if rf, ok := ret.Get(0).(func(string, int32, string) model.ExamAnswerHistoryPage); ok {
+		r0 = rf(examID, pageSize, bookmark)
+	} else {
+		r0 = ret.Get(0).(model.ExamAnswerHistoryPage)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, int32, string) error); ok {
+		r1 = rf(examID, pageSize, bookmark)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SetAnswer provides a mock function with given fields: studentId, examID, questionID, ans
 func (_m *FabricService) SetAnswer(studentId string, examID string, questionID string, ans string) error {
 	ret := _m.Called(studentId, examID, questionID, ans)
@@ -1,25 +1,77 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	model "github.com/deeraj-kumar/exam-audit/domain"
 	"github.com/deeraj-kumar/exam-audit/service/contract"
 	fabricutils "github.com/deeraj-kumar/exam-audit/service/fabricUtils"
 	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"google.golang.org/protobuf/proto"
 )
 
+const (
+	// HistoryPageSize is the number of answer keys fetched per GetExamAnswerHistory call
+	HistoryPageSize = 500
+	// MigrationBatchSize is the number of legacy answer keys moved per MigrateLegacyAnswers transaction
+	MigrationBatchSize = 200
+)
+
+// EventReconnectDelay is the pause before ExamEvents reopens a broken event stream
+var EventReconnectDelay = time.Second
+
+// ledgerChaincodeName is the system chaincode answering ledger queries such as the chain height
+const ledgerChaincodeName = "qscc"
+
 type FabricService interface {
 	SetAnswer(studentId, examID, questionID, ans string) error
 	QueryEdittedAnswersByExam(exam model.Exam, students []model.Student) ([]model.Answer, error)
+	QueryExamAnswerHistoryPage(examID string, pageSize int32, bookmark string) (model.ExamAnswerHistoryPage, error)
+	MigrateLegacyAnswers(examID string) (int, error)
+	ScheduleExam(examID string, startTime, endTime int64) error
+	SetExamState(examID, state string) error
+	QueryExamSchedule(examID string) (model.ExamSchedule, error)
+	// LedgerHeight returns the number of blocks on the channel , the number of the next block to commit
+	LedgerHeight() (uint64, error)
+	// ExamEvents streams the answer and exam lifecycle events committed from startBlock on until ctx is done ,
+	// resuming after the last delivered event when the stream breaks
+	ExamEvents(ctx context.Context, startBlock uint64) (<-chan model.LedgerEvent, error)
 	Close()
 }
 
+// ErrAnswerRejected is returned by SetAnswer when the ledger refuses an answer because its exam is not open
+var ErrAnswerRejected = errors.New("answer rejected by the exam schedule")
+
+// ErrExamNotScheduled is returned by QueryExamSchedule for an exam that has no schedule on the ledger
+var ErrExamNotScheduled = errors.New("exam not scheduled on the ledger")
+
+// examMissing is the phrase the chaincode puts in the error for an exam it has no record of
+const examMissing = "does not exist"
+
+// answersRejected is the phrase the chaincode puts in every error refusing an answer because of the exam schedule
+const answersRejected = "does not accept answers"
+
+// examStateTransactions maps every state an exam can move to onto the chaincode function moving it there
+var examStateTransactions = map[string]string{
+	model.ExamStateOpen:    "OpenExam",
+	model.ExamStateClosed:  "CloseExam",
+	model.ExamStateAudited: "MarkExamAudited",
+}
+
 type fabricService struct {
-	gateway  *client.Gateway
-	contract contract.Contract
+	gateway     *client.Gateway
+	contract    contract.Contract
+	ledger      contract.Contract
+	channelName string
 }
 
 func NewFabricService(peerEndpoint, peerTLSCertPath, certPath, keyPath, mspID, channelName, chaincodeName string) (FabricService, error) {
@@ -128,8 +180,10 @@ func NewFabricService(peerEndpoint, peerTLSCertPath, certPath, keyPath, mspID, c
 	c := fabricutils.GetContract(gw, channelName, chaincodeName)
 
 	return &fabricService{
-		gateway:  gw,
-		contract: c,
+		gateway:     gw,
+		contract:    c,
+		ledger:      fabricutils.GetContract(gw, channelName, ledgerChaincodeName),
+		channelName: channelName,
 	}, nil
 }
 
@@ -144,42 +198,214 @@ func (s *fabricService) SetAnswer(studentId, examID, questionID, ans string) err
 		return fmt.Errorf("contract not initialized")
 	}
 
-	compositeKey := fmt.Sprintf("Answer~%s~%s~%s", examID, questionID, studentId)
-
-	_, err := s.contract.SubmitTransaction("SetAnswer", compositeKey, ans)
+	// the contract builds the composite key from the separate ids
+	_, err := s.contract.SubmitTransaction("SetAnswer", examID, questionID, studentId, ans)
 	if err != nil {
+		if strings.Contains(err.Error(), answersRejected) {
+			return fmt.Errorf("%w: %v", ErrAnswerRejected, err)
+		}
 		return fmt.Errorf("failed submitting SetAnswer: %w", err)
 	}
 	return nil
 }
 
+// ScheduleExam creates the exam on the ledger in the draft state with the given answer window
+func (s *fabricService) ScheduleExam(examID string, startTime, endTime int64) error {
+	if s.contract == nil {
+		return fmt.Errorf("contract not initialized")
+	}
+	_, err := s.contract.SubmitTransaction("CreateExam", examID, strconv.FormatInt(startTime, 10), strconv.FormatInt(endTime, 10))
+	if err != nil {
+		return fmt.Errorf("failed submitting CreateExam for exam %s: %w", examID, err)
+	}
+	return nil
+}
+
+// SetExamState moves the exam to the next lifecycle state , the ledger rejects any other move
+func (s *fabricService) SetExamState(examID, state string) error {
+	if s.contract == nil {
+		return fmt.Errorf("contract not initialized")
+	}
+	fn, ok := examStateTransactions[state]
+	if !ok {
+		return fmt.Errorf("exam can't be moved to state %q", state)
+	}
+	if _, err := s.contract.SubmitTransaction(fn, examID); err != nil {
+		return fmt.Errorf("failed submitting %s for exam %s: %w", fn, examID, err)
+	}
+	return nil
+}
+
+func (s *fabricService) QueryExamSchedule(examID string) (model.ExamSchedule, error) {
+	if s.contract == nil {
+		return model.ExamSchedule{}, fmt.Errorf("contract not initialized")
+	}
+	transactionResp, err := s.contract.EvaluateTransaction("GetExam", examID)
+	if err != nil {
+		if strings.Contains(err.Error(), examMissing) {
+			return model.ExamSchedule{}, fmt.Errorf("%w: %v", ErrExamNotScheduled, err)
+		}
+		return model.ExamSchedule{}, fmt.Errorf("failed to get the schedule of exam %s , due to %v", examID, err)
+	}
+	var schedule model.ExamSchedule
+	if err := json.Unmarshal(transactionResp, &schedule); err != nil {
+		return model.ExamSchedule{}, fmt.Errorf("failed to unmarshal %v , err - %v ", transactionResp, err)
+	}
+	return schedule, nil
+}
+
+// QueryEdittedAnswersByExam reads the revision history of every answer of the exam given by the students passed
+// in , or by anyone when students is nil , a page of keys per gateway call . Answers not migrated from the old plain keys yet come in the last
+// pages , see MigrateLegacyAnswers
 func (s *fabricService) QueryEdittedAnswersByExam(exam model.Exam, students []model.Student) ([]model.Answer, error) {
+	if s.contract == nil {
+		return nil, fmt.Errorf("contract not initialized")
+	}
+
+	roster := make(map[string]bool, len(students))
+	for _, std := range students {
+		roster[std.StudentID] = true
+	}
+
 	var answers []model.Answer
+	bookmark := ""
+	for {
+		page, err := s.QueryExamAnswerHistoryPage(exam.ExamID, HistoryPageSize, bookmark)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range page.Records {
+			if students != nil && !roster[record.StudentID] {
+				continue
+			}
+			for _, revision := range record.Revisions {
+				answers = append(answers, model.Answer{Ans: revision.Value, QuestionID: record.QuestionID, StudentID: record.StudentID, SubmittedAt: revision.Timestamp, TxID: revision.TxID})
+			}
+		}
+		// a short page ends the composite keys but not the legacy ones , only an empty bookmark ends the exam
+		if page.Bookmark == "" || page.Bookmark == bookmark {
+			break
+		}
+		bookmark = page.Bookmark
+	}
+	return answers, nil
+}
+
+func (s *fabricService) QueryExamAnswerHistoryPage(examID string, pageSize int32, bookmark string) (model.ExamAnswerHistoryPage, error) {
+	if s.contract == nil {
+		return model.ExamAnswerHistoryPage{}, fmt.Errorf("contract not initialized")
+	}
+	transactionResp, err := s.contract.EvaluateTransaction("GetExamAnswerHistory", examID, strconv.Itoa(int(pageSize)), bookmark)
+	if err != nil {
+		return model.ExamAnswerHistoryPage{}, fmt.Errorf("failed to get the answer history of exam %s , bookmark %q , due to %v", examID, bookmark, err)
+	}
+	var page model.ExamAnswerHistoryPage
+	if err := json.Unmarshal(transactionResp, &page); err != nil {
+		return model.ExamAnswerHistoryPage{}, fmt.Errorf("failed to unmarshal %v , err - %v ", transactionResp, err)
+	}
+	return page, nil
+}
+
+// MigrateLegacyAnswers moves every answer of an exam from the old plain keys to composite keys , a batch per
+// transaction , and returns how many answers were moved
+func (s *fabricService) MigrateLegacyAnswers(examID string) (int, error) {
+	if s.contract == nil {
+		return 0, fmt.Errorf("contract not initialized")
+	}
+	total := 0
+	for {
+		resp, err := s.contract.SubmitTransaction("MigrateLegacyAnswers", examID, strconv.Itoa(MigrationBatchSize))
+		if err != nil {
+			return total, fmt.Errorf("failed submitting MigrateLegacyAnswers for exam %s after %d answers: %w", examID, total, err)
+		}
+		migrated, err := strconv.Atoi(strings.TrimSpace(string(resp)))
+		if err != nil {
+			return total, fmt.Errorf("unexpected MigrateLegacyAnswers response %q: %w", resp, err)
+		}
+		total += migrated
+		if migrated < MigrationBatchSize {
+			return total, nil
+		}
+	}
+}
+
+func (s *fabricService) LedgerHeight() (uint64, error) {
+	if s.ledger == nil {
+		return 0, fmt.Errorf("ledger contract not initialized")
+	}
+	transactionResp, err := s.ledger.EvaluateTransaction("GetChainInfo", s.channelName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the chain info of channel %s , due to %v", s.channelName, err)
+	}
+	var info common.BlockchainInfo
+	if err := proto.Unmarshal(transactionResp, &info); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal chain info , err - %v", err)
+	}
+	return info.GetHeight(), nil
+}
+
+func (s *fabricService) ExamEvents(ctx context.Context, startBlock uint64) (<-chan model.LedgerEvent, error) {
 	if s.contract == nil {
 		return nil, fmt.Errorf("contract not initialized")
 	}
-	examID := exam.ExamID
-	for _, q := range exam.Questions {
-		var answerHistoryRecords []model.AnswerHistory
-		for _, std := range students {
-			key := fmt.Sprintf("Answer~%s~%s~%s", examID, q.QuestionID, std.StudentID)
-			transactionResp, err := s.contract.EvaluateTransaction("GetAnswerRevisionHistory", key)
-			if err != nil {
-				return nil, fmt.Errorf("failed to get the answer revision history for the key %s , due to %v", key, err)
+	stream, err := s.contract.ChaincodeEvents(ctx, client.WithStartBlock(startBlock))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen to chaincode events from block %d , due to %v", startBlock, err)
+	}
+
+	events := make(chan model.LedgerEvent)
+	go func() {
+		defer close(events)
+		// the checkpoint moves past every event handed out , a reconnect resumes right after the last one
+		checkpointer := new(client.InMemoryCheckpointer)
+		for {
+			for event := range stream {
+				ledgerEvent, err := decodeLedgerEvent(event)
+				if err != nil {
+					log.Printf("skipping chaincode event %s of tx %s , err - %v", event.EventName, event.TransactionID, err)
+				} else {
+					select {
+					case events <- ledgerEvent:
+					case <-ctx.Done():
+						return
+					}
+				}
+				checkpointer.CheckpointChaincodeEvent(event)
 			}
-			if len(transactionResp) == 0 {
-				return nil, fmt.Errorf("transaction response data can't be empty , key %s", key)
+
+			if ctx.Err() != nil {
+				return
 			}
-			fmt.Printf("Ledger raw response for History Query , key - %s , resp - %s", key, string(transactionResp))
-			if err := json.Unmarshal(transactionResp, &answerHistoryRecords); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal %v , err - %v ", transactionResp, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(EventReconnectDelay):
 			}
-			for _, record := range answerHistoryRecords {
-				answers = append(answers, model.Answer{Ans: record.Value, QuestionID: q.QuestionID, StudentID: std.StudentID, SubmittedAt: record.Timestamp})
+			stream, err = s.contract.ChaincodeEvents(ctx, client.WithStartBlock(startBlock), client.WithCheckpoint(checkpointer))
+			if err != nil {
+				log.Printf("failed to resume chaincode events after block %d , err - %v", checkpointer.BlockNumber(), err)
+				return
 			}
-
 		}
+	}()
+	return events, nil
+}
 
+// decodeLedgerEvent reads the payload of an answer or exam lifecycle event
+func decodeLedgerEvent(event *client.ChaincodeEvent) (model.LedgerEvent, error) {
+	switch event.EventName {
+	case model.LedgerEventAnswerSubmitted, model.LedgerEventExamStateChanged:
+	default:
+		return model.LedgerEvent{}, fmt.Errorf("unknown event")
 	}
-	return answers, nil
+	var ledgerEvent model.LedgerEvent
+	if err := json.Unmarshal(event.Payload, &ledgerEvent); err != nil {
+		return model.LedgerEvent{}, err
+	}
+	ledgerEvent.Name = event.EventName
+	ledgerEvent.TxID = event.TransactionID
+	if ledgerEvent.Answer != nil {
+		ledgerEvent.Answer.TxID = event.TransactionID
+	}
+	return ledgerEvent, nil
 }
@@ -0,0 +1,67 @@
+package fabricsvctest
+
+import (
+	"context"
+	"testing"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+	"github.com/deeraj-kumar/exam-audit/service"
+	"github.com/deeraj-kumar/exam-audit/service/mocks"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestLedgerHeight(t *testing.T) {
+	info, err := proto.Marshal(&common.BlockchainInfo{Height: 42})
+	assert.Nil(t, err)
+	mockContract := new(mocks.Contract)
+	mockContract.On("EvaluateTransaction", "GetChainInfo", mockChannelName).Return(info, nil)
+	fabricSvc := newTestService(t, mockContract)
+
+	height, err := fabricSvc.LedgerHeight()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(42), height)
+}
+
+func TestExamEvents_ResumesAfterBrokenStream(t *testing.T) {
+	original := service.EventReconnectDelay
+	service.EventReconnectDelay = 0
+	defer func() {
+		service.EventReconnectDelay = original
+	}()
+
+	first := make(chan *client.ChaincodeEvent, 3)
+	first <- &client.ChaincodeEvent{BlockNumber: 42, TransactionID: "tx1", EventName: model.LedgerEventAnswerSubmitted,
+		Payload: []byte(`{"examID":"exam1","answer":{"questionID":"q1","studentID":"s1","ans":"A","submittedAt":1500}}`)}
+	first <- &client.ChaincodeEvent{BlockNumber: 42, TransactionID: "tx2", EventName: "SomethingElse", Payload: []byte(`{}`)}
+	close(first)
+	second := make(chan *client.ChaincodeEvent, 1)
+	second <- &client.ChaincodeEvent{BlockNumber: 43, TransactionID: "tx3", EventName: model.LedgerEventExamStateChanged,
+		Payload: []byte(`{"examID":"exam1","state":"closed"}`)}
+
+	mockContract := new(mocks.Contract)
+	// the first stream starts at the given block , the reconnect passes the checkpoint as well
+	mockContract.On("ChaincodeEvents", mock.Anything, mock.Anything).Return((<-chan *client.ChaincodeEvent)(first), nil).Once()
+	mockContract.On("ChaincodeEvents", mock.Anything, mock.Anything, mock.Anything).Return((<-chan *client.ChaincodeEvent)(second), nil).Once()
+	fabricSvc := newTestService(t, mockContract)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := fabricSvc.ExamEvents(ctx, 42)
+	assert.Nil(t, err)
+
+	answer := <-events
+	assert.Equal(t, model.LedgerEvent{Name: model.LedgerEventAnswerSubmitted, TxID: "tx1", ExamID: "exam1",
+		Answer: &model.Answer{QuestionID: "q1", StudentID: "s1", Ans: "A", SubmittedAt: 1500, TxID: "tx1"}}, answer)
+	state := <-events
+	assert.Equal(t, model.LedgerEvent{Name: model.LedgerEventExamStateChanged, TxID: "tx3", ExamID: "exam1", State: model.ExamStateClosed}, state)
+
+	// the gateway closes the stream once ctx is done
+	cancel()
+	close(second)
+	for range events {
+	}
+	mockContract.AssertExpectations(t)
+}
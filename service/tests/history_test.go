@@ -0,0 +1,150 @@
+package fabricsvctest
+
+import (
+	"crypto/x509"
+	"strconv"
+	"testing"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+	"github.com/deeraj-kumar/exam-audit/service"
+	"github.com/deeraj-kumar/exam-audit/service/contract"
+	fabricutils "github.com/deeraj-kumar/exam-audit/service/fabricUtils"
+	"github.com/deeraj-kumar/exam-audit/service/mocks"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+// newTestService builds a FabricService on top of mockContract with every gateway dependency stubbed out
+func newTestService(t *testing.T, mockContract *mocks.Contract) service.FabricService {
+	originalGetCertPool, originalGetGrpcClient, originalGetFabricGateway := fabricutils.GetCertPool, fabricutils.GetGrpcClient, fabricutils.GetFabricGateway
+	originalGetId, originalGetSigner, originalGetContract := fabricutils.GetId, fabricutils.GetSigner, fabricutils.GetContract
+	t.Cleanup(func() {
+		fabricutils.GetCertPool, fabricutils.GetGrpcClient, fabricutils.GetFabricGateway = originalGetCertPool, originalGetGrpcClient, originalGetFabricGateway
+		fabricutils.GetId, fabricutils.GetSigner, fabricutils.GetContract = originalGetId, originalGetSigner, originalGetContract
+	})
+
+	fabricutils.GetCertPool = func(peerTLSCertPath, dir string) (*x509.CertPool, error) {
+		return &x509.CertPool{}, nil
+	}
+	fabricutils.GetGrpcClient = func(peerEndpoint string, cp *x509.CertPool) (*grpc.ClientConn, error) {
+		return &grpc.ClientConn{}, nil
+	}
+	fabricutils.GetFabricGateway = func(id identity.Identity, signer identity.Sign, grpcClient *grpc.ClientConn) (*client.Gateway, error) {
+		return &client.Gateway{}, nil
+	}
+	fabricutils.GetId = func(certPath, dir string) (*identity.X509Identity, error) {
+		return &identity.X509Identity{}, nil
+	}
+	fabricutils.GetSigner = func(keyPath, dir string) (identity.Sign, error) {
+		var x identity.Sign
+		return x, nil
+	}
+	fabricutils.GetContract = func(gw *client.Gateway, channelName, chainCodeName string) contract.Contract {
+		return mockContract
+	}
+
+	fabricSvc, err := service.NewFabricService(mockPeerEP, "", "", "", mockMspID, mockChannelName, mockChaincodeName)
+	assert.Nil(t, err)
+	return fabricSvc
+}
+
+var pageSize = strconv.Itoa(service.HistoryPageSize)
+
+func TestQueryEdittedAnswersByExam_Paginated(t *testing.T) {
+	mockContract := new(mocks.Contract)
+	mockContract.
+		On("EvaluateTransaction", "GetExamAnswerHistory", "exam1", pageSize, "").
+		Return([]byte(`{"records":[
+			{"questionID":"q1","studentID":"s1","revisions":[{"timestamp":100,"value":"A"},{"timestamp":130,"value":"B"}]},
+			{"questionID":"q1","studentID":"s9","revisions":[{"timestamp":110,"value":"C"}]}
+		],"bookmark":"next","fetchedRecordsCount":`+pageSize+`}`), nil)
+	mockContract.
+		On("EvaluateTransaction", "GetExamAnswerHistory", "exam1", pageSize, "next").
+		Return([]byte(`{"records":[{"questionID":"q2","studentID":"s2","revisions":[{"timestamp":200,"value":"D"}]}],"bookmark":"","fetchedRecordsCount":1}`), nil)
+
+	fabricSvc := newTestService(t, mockContract)
+	answers, err := fabricSvc.QueryEdittedAnswersByExam(model.Exam{ExamID: "exam1"}, []model.Student{{StudentID: "s1"}, {StudentID: "s2"}})
+	assert.Nil(t, err)
+
+	// s9 is not on the roster
+	assert.Equal(t, []model.Answer{
+		{QuestionID: "q1", StudentID: "s1", Ans: "A", SubmittedAt: 100},
+		{QuestionID: "q1", StudentID: "s1", Ans: "B", SubmittedAt: 130},
+		{QuestionID: "q2", StudentID: "s2", Ans: "D", SubmittedAt: 200},
+	}, answers)
+	mockContract.AssertNumberOfCalls(t, "EvaluateTransaction", 2)
+}
+
+func TestQueryEdittedAnswersByExam_EveryParticipant(t *testing.T) {
+	mockContract := new(mocks.Contract)
+	mockContract.
+		On("EvaluateTransaction", "GetExamAnswerHistory", "exam1", pageSize, "").
+		Return([]byte(`{"records":[
+			{"questionID":"q1","studentID":"s1","revisions":[{"timestamp":100,"value":"A"}]},
+			{"questionID":"q1","studentID":"s9","revisions":[{"timestamp":110,"value":"C"}]}
+		],"bookmark":"","fetchedRecordsCount":2}`), nil)
+
+	fabricSvc := newTestService(t, mockContract)
+	answers, err := fabricSvc.QueryEdittedAnswersByExam(model.Exam{ExamID: "exam1"}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []model.Answer{
+		{QuestionID: "q1", StudentID: "s1", Ans: "A", SubmittedAt: 100},
+		{QuestionID: "q1", StudentID: "s9", Ans: "C", SubmittedAt: 110},
+	}, answers)
+}
+
+func TestQueryEdittedAnswersByExam_MixedKeys(t *testing.T) {
+	// the composite keys end in a short page , the contract continues with the legacy range under a new bookmark
+	mockContract := new(mocks.Contract)
+	mockContract.
+		On("EvaluateTransaction", "GetExamAnswerHistory", "exam1", pageSize, "").
+		Return([]byte(`{"records":[{"questionID":"q1","studentID":"s1","revisions":[{"timestamp":100,"value":"A"}]}],"bookmark":"Answer~exam1~","fetchedRecordsCount":1}`), nil)
+	mockContract.
+		On("EvaluateTransaction", "GetExamAnswerHistory", "exam1", pageSize, "Answer~exam1~").
+		Return([]byte(`{"records":[{"questionID":"q1","studentID":"s2","revisions":[{"timestamp":90,"value":"B"}]}],"bookmark":"","fetchedRecordsCount":1}`), nil)
+
+	fabricSvc := newTestService(t, mockContract)
+	answers, err := fabricSvc.QueryEdittedAnswersByExam(model.Exam{ExamID: "exam1"}, []model.Student{{StudentID: "s1"}, {StudentID: "s2"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []model.Answer{
+		{QuestionID: "q1", StudentID: "s1", Ans: "A", SubmittedAt: 100},
+		{QuestionID: "q1", StudentID: "s2", Ans: "B", SubmittedAt: 90},
+	}, answers)
+	mockContract.AssertNumberOfCalls(t, "EvaluateTransaction", 2)
+}
+
+func TestQueryEdittedAnswersByExam_SkippedQuestions(t *testing.T) {
+	mockContract := new(mocks.Contract)
+	mockContract.
+		On("EvaluateTransaction", "GetExamAnswerHistory", "exam1", pageSize, "").
+		Return([]byte(`{"records":[
+			{"questionID":"q1","studentID":"s1","revisions":[]},
+			{"questionID":"q2","studentID":"s1","revisions":null},
+			{"questionID":"q3","studentID":"s1","revisions":[{"timestamp":100,"value":"C"}]}
+		],"bookmark":"","fetchedRecordsCount":3}`), nil)
+
+	fabricSvc := newTestService(t, mockContract)
+	exam := model.Exam{ExamID: "exam1", Questions: []model.Question{{QuestionID: "q1"}, {QuestionID: "q2"}, {QuestionID: "q3"}}}
+	answers, err := fabricSvc.QueryEdittedAnswersByExam(exam, []model.Student{{StudentID: "s1"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []model.Answer{{QuestionID: "q3", StudentID: "s1", Ans: "C", SubmittedAt: 100}}, answers)
+}
+
+func TestMigrateLegacyAnswers_Batches(t *testing.T) {
+	batch := strconv.Itoa(service.MigrationBatchSize)
+	mockContract := new(mocks.Contract)
+	mockContract.
+		On("SubmitTransaction", "MigrateLegacyAnswers", "exam1", batch).
+		Return([]byte(batch), nil).Once()
+	mockContract.
+		On("SubmitTransaction", "MigrateLegacyAnswers", "exam1", batch).
+		Return([]byte("12"), nil).Once()
+
+	fabricSvc := newTestService(t, mockContract)
+	migrated, err := fabricSvc.MigrateLegacyAnswers("exam1")
+	assert.Nil(t, err)
+	assert.Equal(t, service.MigrationBatchSize+12, migrated)
+	mockContract.AssertNumberOfCalls(t, "SubmitTransaction", 2)
+}
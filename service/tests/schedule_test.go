@@ -0,0 +1,73 @@
+package fabricsvctest
+
+import (
+	"errors"
+	"testing"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+	"github.com/deeraj-kumar/exam-audit/service"
+	"github.com/deeraj-kumar/exam-audit/service/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleExam(t *testing.T) {
+	mockContract := new(mocks.Contract)
+	mockContract.
+		On("SubmitTransaction", "CreateExam", "exam1", "1000", "4600").
+		Return([]byte(""), nil)
+
+	fabricSvc := newTestService(t, mockContract)
+	assert.Nil(t, fabricSvc.ScheduleExam("exam1", 1000, 4600))
+	mockContract.AssertExpectations(t)
+}
+
+func TestSetExamState(t *testing.T) {
+	mockContract := new(mocks.Contract)
+	for fn := range map[string]bool{"OpenExam": true, "CloseExam": true, "MarkExamAudited": true} {
+		mockContract.On("SubmitTransaction", fn, "exam1").Return([]byte(""), nil)
+	}
+
+	fabricSvc := newTestService(t, mockContract)
+	for _, state := range []string{model.ExamStateOpen, model.ExamStateClosed, model.ExamStateAudited} {
+		assert.Nil(t, fabricSvc.SetExamState("exam1", state))
+	}
+	mockContract.AssertExpectations(t)
+
+	// draft is only ever the initial state
+	assert.NotNil(t, fabricSvc.SetExamState("exam1", model.ExamStateDraft))
+	mockContract.AssertNumberOfCalls(t, "SubmitTransaction", 3)
+}
+
+func TestQueryExamSchedule(t *testing.T) {
+	mockContract := new(mocks.Contract)
+	mockContract.
+		On("EvaluateTransaction", "GetExam", "exam1").
+		Return([]byte(`{"examID":"exam1","startTime":1000,"endTime":4600,"state":"open"}`), nil)
+
+	fabricSvc := newTestService(t, mockContract)
+	schedule, err := fabricSvc.QueryExamSchedule("exam1")
+	assert.Nil(t, err)
+	assert.Equal(t, model.ExamSchedule{ExamID: "exam1", StartTime: 1000, EndTime: 4600, State: model.ExamStateOpen}, schedule)
+}
+
+func TestQueryExamSchedule_NotScheduled(t *testing.T) {
+	mockContract := new(mocks.Contract)
+	mockContract.
+		On("EvaluateTransaction", "GetExam", "legacy").
+		Return(nil, errors.New("chaincode response 500, exam legacy does not exist"))
+
+	fabricSvc := newTestService(t, mockContract)
+	_, err := fabricSvc.QueryExamSchedule("legacy")
+	assert.True(t, errors.Is(err, service.ErrExamNotScheduled))
+}
+
+func TestSetAnswer_RejectedOutsideWindow(t *testing.T) {
+	mockContract := new(mocks.Contract)
+	mockContract.
+		On("SubmitTransaction", "SetAnswer", "exam1", "q1", "s1", "A").
+		Return(nil, errors.New("chaincode response 500, exam exam1 does not accept answers , it is closed"))
+
+	fabricSvc := newTestService(t, mockContract)
+	err := fabricSvc.SetAnswer("s1", "exam1", "q1", "A")
+	assert.True(t, errors.Is(err, service.ErrAnswerRejected))
+}
@@ -75,7 +75,7 @@ func TestSetAnswer_Success(t *testing.T) {
 	}()
 
 	mockContract.
-		On("SubmitTransaction", "SetAnswer", "Answer~exam1~q1~s1", "A").
+		On("SubmitTransaction", "SetAnswer", "exam1", "q1", "s1", "A").
 		Return([]byte("OK"), nil)
 
 	fabricSvc, err := service.NewFabricService(mockPeerEP, "", "", "", mockMspID, mockChannelName, mockChaincodeName)
@@ -138,7 +138,7 @@ func TestSetAnswer_Fail(t *testing.T) {
 	}()
 
 	mockContract.
-		On("SubmitTransaction", "SetAnswer", "Answer~exam1~q1~s1", "A").
+		On("SubmitTransaction", "SetAnswer", "exam1", "q1", "s1", "A").
 		Return([]byte("OK"), fmt.Errorf("some error"))
 
 	fabricSvc, err := service.NewFabricService(mockPeerEP, "", "", "", mockMspID, mockChannelName, mockChaincodeName)
@@ -0,0 +1,345 @@
+package util
+
+import (
+	"context"
+	"sync"
+
+	"github.com/deeraj-kumar/exam-audit/candidate"
+	"github.com/deeraj-kumar/exam-audit/config"
+	model "github.com/deeraj-kumar/exam-audit/domain"
+	"github.com/deeraj-kumar/exam-audit/scoring"
+)
+
+// IncrementalAudit keeps the pair scores of a live exam and rescores only the pairs a new revision can change
+type IncrementalAudit struct {
+	mu      sync.RWMutex
+	exam    model.Exam
+	rb      *reportBuilder
+	pruning model.PruningConfig
+	answers map[string]map[string][]model.AnswerRevision
+	pairs   map[[2]string]*pairState
+
+	// snapshot caches the report of the current revisions, cleared by apply
+	snapMu   sync.Mutex
+	snapshot *AuditSnapshot
+
+	subMu       sync.Mutex
+	subscribers map[chan struct{}]struct{}
+	closed      bool
+}
+
+// AuditSnapshot is a live report along with the answers it was built from
+type AuditSnapshot struct {
+	Report   model.AdjacencyList
+	Metadata model.ReportMetadata
+	Answers  map[string]map[string][]model.AnswerRevision
+}
+
+// pairState caches the results of a pair in (StudentA, StudentB) order
+type pairState struct {
+	questions map[string]questionResult
+	exam      *scoring.Result
+	item      model.AdjacencyItem
+}
+
+// NewIncrementalAudit scores every pair of the given answers once
+func NewIncrementalAudit(exam model.Exam, studentAnswersMap map[string]map[string][]model.AnswerRevision) (*IncrementalAudit, error) {
+	answers := make(map[string]map[string][]model.AnswerRevision, len(studentAnswersMap))
+	for sid, byQuestion := range studentAnswersMap {
+		answers[sid] = make(map[string][]model.AnswerRevision, len(byQuestion))
+		for qID, revisions := range byQuestion {
+			answers[sid][qID] = append([]model.AnswerRevision(nil), revisions...)
+		}
+	}
+
+	rb, err := newReportBuilder(exam, answers)
+	if err != nil {
+		return nil, err
+	}
+	ia := &IncrementalAudit{
+		exam:    exam,
+		rb:      rb,
+		pruning: config.Cfg.Scoring.Pruning,
+		answers: answers,
+		pairs:   make(map[[2]string]*pairState),
+
+		subscribers: make(map[chan struct{}]struct{}),
+	}
+	studentIDs := sortedStudentIDs(answers)
+	for i, aID := range studentIDs {
+		for _, bID := range studentIDs[i+1:] {
+			ia.scorePair(aID, bID)
+		}
+	}
+	return ia, nil
+}
+
+// Exam returns the exam definition the audit scores against
+func (ia *IncrementalAudit) Exam() model.Exam {
+	return ia.exam
+}
+
+// Subscribe returns a channel signalled after each revision and a cancel func, signals coalesce
+func (ia *IncrementalAudit) Subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	ia.subMu.Lock()
+	if ia.closed {
+		close(ch)
+	} else {
+		ia.subscribers[ch] = struct{}{}
+	}
+	ia.subMu.Unlock()
+	return ch, func() {
+		ia.subMu.Lock()
+		delete(ia.subscribers, ch)
+		ia.subMu.Unlock()
+	}
+}
+
+// Close ends every subscription, later subscribers get a closed channel
+func (ia *IncrementalAudit) Close() {
+	ia.subMu.Lock()
+	defer ia.subMu.Unlock()
+	ia.closed = true
+	for ch := range ia.subscribers {
+		close(ch)
+		delete(ia.subscribers, ch)
+	}
+}
+
+// Apply records a revision, rescores the pairs it affects and notifies subscribers
+func (ia *IncrementalAudit) Apply(ans model.Answer) {
+	ia.apply(ans)
+
+	ia.subMu.Lock()
+	defer ia.subMu.Unlock()
+	for ch := range ia.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (ia *IncrementalAudit) apply(ans model.Answer) {
+	ia.mu.Lock()
+	defer ia.mu.Unlock()
+
+	sid, qID := ans.StudentID, ans.QuestionID
+	_, known := ia.answers[sid]
+	if !known {
+		ia.answers[sid] = make(map[string][]model.AnswerRevision)
+		ia.rb.cohort.AddStudent()
+	}
+	before := ia.answers[sid][qID]
+	// keep transaction time order in a fresh array, earlier snapshots still hold the old one
+	at := len(before)
+	for at > 0 && before[at-1].SubmittedAt > ans.SubmittedAt {
+		at--
+	}
+	after := make([]model.AnswerRevision, 0, len(before)+1)
+	after = append(after, before[:at]...)
+	after = append(after, model.AnswerRevision{SubmittedAt: ans.SubmittedAt, Ans: ans.Ans})
+	after = append(after, before[at:]...)
+	ia.answers[sid][qID] = after
+	ia.rb.cohort.Update(qID, before, after)
+	ia.snapshot = nil
+
+	for other := range ia.answers {
+		if other == sid {
+			continue
+		}
+		key := pairKey(sid, other)
+		if !known {
+			ia.scorePair(key[0], key[1])
+			continue
+		}
+		state := ia.pairs[key]
+		ia.rescoreQuestion(key, state, qID)
+		state.exam = ia.rb.scoreExam(ia.answers[key[0]], ia.answers[key[1]])
+		ia.assemble(key, state)
+	}
+
+	if len(before) == 0 && ia.rb.pipeline.ReadsSkipRates() {
+		ia.rescoreSkips(sid, qID, !known)
+	}
+
+	// the cohort moved for qID, rescore it for the other pairs reading its rarity
+	var finals map[string]bool
+	if len(before) > 0 {
+		finals = map[string]bool{before[len(before)-1].Ans: true, after[len(after)-1].Ans: true}
+	}
+	groups := make(map[string][]string)
+	for other, byQuestion := range ia.answers {
+		revisions := byQuestion[qID]
+		if other == sid || len(revisions) == 0 {
+			continue
+		}
+		final := revisions[len(revisions)-1].Ans
+		if finals == nil || finals[final] {
+			groups[final] = append(groups[final], other)
+		}
+	}
+	for _, members := range groups {
+		for x := 0; x < len(members); x++ {
+			for y := x + 1; y < len(members); y++ {
+				key := pairKey(members[x], members[y])
+				state := ia.pairs[key]
+				ia.rescoreQuestion(key, state, qID)
+				ia.assemble(key, state)
+			}
+		}
+	}
+}
+
+// Snapshot returns the current report, shared between callers so it must not be modified
+func (ia *IncrementalAudit) Snapshot() AuditSnapshot {
+	ia.mu.RLock()
+	defer ia.mu.RUnlock()
+	ia.snapMu.Lock()
+	defer ia.snapMu.Unlock()
+	if ia.snapshot == nil {
+		snapshot := ia.buildSnapshot()
+		ia.snapshot = &snapshot
+	}
+	return *ia.snapshot
+}
+
+func (ia *IncrementalAudit) buildSnapshot() AuditSnapshot {
+	answers := make(map[string]map[string][]model.AnswerRevision, len(ia.answers))
+	for sid, byQuestion := range ia.answers {
+		answers[sid] = make(map[string][]model.AnswerRevision, len(byQuestion))
+		for qID, revisions := range byQuestion {
+			answers[sid][qID] = revisions
+		}
+	}
+
+	studentIDs := sortedStudentIDs(answers)
+	candidates := candidate.Select(ia.exam, studentIDs, answers, ia.pruning)
+	var pairs model.AdjacencyList
+	for i, aID := range studentIDs {
+		partners := allPartners(i, len(studentIDs))
+		if candidates != nil {
+			partners = candidates[i]
+		}
+		for _, j := range partners {
+			item := ia.pairs[[2]string{aID, studentIDs[j]}].item
+			// the report writes into Reason, keep the cached one untouched
+			reason := *item.Reason
+			item.Reason = &reason
+			pairs = append(pairs, item)
+		}
+	}
+
+	// a background context never cancels, so finish can't fail
+	record, meta, _ := ia.rb.finish(context.Background(), answers, pairs)
+	return AuditSnapshot{Report: record, Metadata: meta, Answers: answers}
+}
+
+func (ia *IncrementalAudit) scorePair(aID, bID string) {
+	a, b := ia.answers[aID], ia.answers[bID]
+	state := &pairState{questions: make(map[string]questionResult, len(a)), exam: ia.rb.scoreExam(a, b)}
+	for qID, aRevisions := range a {
+		state.questions[qID] = ia.rb.scoreQuestion(qID, aRevisions, b[qID])
+	}
+	for qID, bRevisions := range b {
+		if _, ok := a[qID]; !ok {
+			state.questions[qID] = ia.rb.scoreQuestion(qID, nil, bRevisions)
+		}
+	}
+	key := [2]string{aID, bID}
+	ia.pairs[key] = state
+	ia.assemble(key, state)
+}
+
+// rescoreQuestion rescores one question of a pair the way scorePair does
+func (ia *IncrementalAudit) rescoreQuestion(key [2]string, state *pairState, qID string) {
+	aRevisions, aOK := ia.answers[key[0]][qID]
+	bRevisions, bOK := ia.answers[key[1]][qID]
+	if !aOK && !bOK {
+		return
+	}
+	state.questions[qID] = ia.rb.scoreQuestion(qID, aRevisions, bRevisions)
+}
+
+// rescoreSkips updates the exam scores reading the skip rate of qID, or all of them when a student joined
+func (ia *IncrementalAudit) rescoreSkips(sid, qID string, joined bool) {
+	skips := func(other string) bool {
+		if !joined {
+			return len(ia.answers[other][qID]) == 0
+		}
+		for _, q := range ia.rb.examOrder {
+			if len(ia.answers[other][q.QuestionID]) == 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	seen := make(map[[2]string]bool)
+	for x := range ia.answers {
+		if x == sid || !skips(x) {
+			continue
+		}
+		for y := range ia.answers {
+			if y == sid || y == x {
+				continue
+			}
+			key := pairKey(x, y)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			state := ia.pairs[key]
+			state.exam = ia.rb.scoreExam(ia.answers[key[0]], ia.answers[key[1]])
+			ia.assemble(key, state)
+		}
+	}
+}
+
+func (ia *IncrementalAudit) assemble(key [2]string, state *pairState) {
+	state.item = ia.rb.assemble(key[0], key[1], ia.answers[key[0]], ia.answers[key[1]], state.questions, state.exam)
+}
+
+func pairKey(x, y string) [2]string {
+	if x > y {
+		return [2]string{y, x}
+	}
+	return [2]string{x, y}
+}
+
+// DiffReports lists the pairs flagged, updated and cleared between prev and next
+func DiffReports(prev, next model.AdjacencyList) []model.SuspicionEvent {
+	before := make(map[[2]string]model.AdjacencyItem, len(prev))
+	for _, item := range prev {
+		before[[2]string{item.StudentA, item.StudentB}] = item
+	}
+
+	var events []model.SuspicionEvent
+	seen := make(map[[2]string]bool, len(next))
+	for _, item := range next {
+		key := [2]string{item.StudentA, item.StudentB}
+		seen[key] = true
+		event := model.SuspicionEvent{StudentA: item.StudentA, StudentB: item.StudentB, Score: item.Score, FlaggedQuestions: item.FlaggedQuestions}
+		if item.Reason != nil {
+			event.Summary = item.Reason.Summary
+		}
+		old, ok := before[key]
+		switch {
+		case !ok:
+			event.Type = model.EventPairFlagged
+		case old.Score != item.Score:
+			event.Type = model.EventScoreChanged
+			event.PreviousScore = old.Score
+		default:
+			continue
+		}
+		events = append(events, event)
+	}
+	for _, item := range prev {
+		if key := [2]string{item.StudentA, item.StudentB}; !seen[key] {
+			events = append(events, model.SuspicionEvent{Type: model.EventPairCleared, StudentA: item.StudentA, StudentB: item.StudentB, PreviousScore: item.Score})
+		}
+	}
+	return events
+}
@@ -0,0 +1,78 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+)
+
+// ErrInvalidSpeed is returned for a replay speed that is neither instant nor a positive multiplier
+var ErrInvalidSpeed = errors.New("invalid replay speed")
+
+// ParseReplaySpeed reads a speed such as 1x , 10x or instant . Instant is returned as zero
+func ParseReplaySpeed(s string) (float64, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" || s == "instant" {
+		return 0, nil
+	}
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(s, "x"), 64)
+	if err != nil || speed <= 0 {
+		return 0, fmt.Errorf("%w , %q", ErrInvalidSpeed, s)
+	}
+	return speed, nil
+}
+
+// Replay feeds the revisions of a finished exam through a fresh incremental audit in timestamp order and emits
+// every report change with the time it happened . Between revisions it waits for the exam time gap divided by
+// speed , a speed of zero replays instantly . Revisions sharing a timestamp are applied together
+func Replay(ctx context.Context, exam model.Exam, answers []model.Answer, speed float64, emit func(model.TimelineEvent)) error {
+	ia, err := NewIncrementalAudit(exam, nil)
+	if err != nil {
+		return err
+	}
+
+	ordered := append([]model.Answer(nil), answers...)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].SubmittedAt < ordered[j].SubmittedAt })
+
+	var prev model.AdjacencyList
+	for i := 0; i < len(ordered); {
+		at := ordered[i].SubmittedAt
+		if i > 0 && speed > 0 {
+			if err := wait(ctx, time.Duration(float64(at-ordered[i-1].SubmittedAt)*float64(time.Second)/speed)); err != nil {
+				return fmt.Errorf("replay cancelled: %w", err)
+			}
+		} else if err := ctx.Err(); err != nil {
+			return fmt.Errorf("replay cancelled: %w", err)
+		}
+
+		for ; i < len(ordered) && ordered[i].SubmittedAt == at; i++ {
+			ia.apply(ordered[i])
+		}
+		next := ia.Snapshot().Report
+		for _, event := range DiffReports(prev, next) {
+			emit(model.TimelineEvent{At: at, OffsetSeconds: at - ordered[0].SubmittedAt, SuspicionEvent: event})
+		}
+		prev = next
+	}
+	return nil
+}
+
+func wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
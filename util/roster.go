@@ -0,0 +1,34 @@
+package util
+
+import (
+	"sort"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+)
+
+// ReconcileRoster reports the discrepancies between the roster and the students seen on the ledger both ways
+func ReconcileRoster(roster []model.Student, participants []string) model.RosterCheck {
+	rostered := make(map[string]bool, len(roster))
+	for _, std := range roster {
+		rostered[std.StudentID] = true
+	}
+	submitted := make(map[string]bool, len(participants))
+	for _, sid := range participants {
+		submitted[sid] = true
+	}
+
+	check := model.RosterCheck{NotOnRoster: []string{}, NoSubmission: []string{}}
+	for sid := range submitted {
+		if !rostered[sid] {
+			check.NotOnRoster = append(check.NotOnRoster, sid)
+		}
+	}
+	for sid := range rostered {
+		if !submitted[sid] {
+			check.NoSubmission = append(check.NoSubmission, sid)
+		}
+	}
+	sort.Strings(check.NotOnRoster)
+	sort.Strings(check.NoSubmission)
+	return check
+}
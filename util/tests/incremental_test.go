@@ -0,0 +1,179 @@
+package util_test
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/deeraj-kumar/exam-audit/config"
+	model "github.com/deeraj-kumar/exam-audit/domain"
+	"github.com/deeraj-kumar/exam-audit/scoring"
+	"github.com/deeraj-kumar/exam-audit/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncrementalAudit_MatchesFullRecompute(t *testing.T) {
+	exam, answers := syntheticAnswers(25, 6)
+	withScoringConfig(t, 2)
+
+	// seed with the first half and stream the rest in , checking against a full recompute along the way
+	seed := len(answers) / 2
+	ia, err := util.NewIncrementalAudit(exam, util.GenerateFlattenedTable(answers[:seed]))
+	assert.Nil(t, err)
+
+	for i := seed; i < len(answers); i++ {
+		ia.Apply(answers[i])
+		if i%40 != 0 && i != len(answers)-1 {
+			continue
+		}
+		want, wantMeta, err := util.GenerateAuditReport(context.Background(), exam, util.GenerateFlattenedTable(answers[:i+1]))
+		assert.Nil(t, err)
+		snapshot := ia.Snapshot()
+		assert.Equal(t, want, snapshot.Report)
+		assert.Equal(t, wantMeta, snapshot.Metadata)
+	}
+}
+
+func TestIncrementalAudit_FromEmptyWithSignificance(t *testing.T) {
+	exam, answers := syntheticAnswers(12, 4)
+	withScoringConfig(t, 1)
+	config.Cfg.Scoring.Aggregation.Combiner = scoring.CombinerMean
+	config.Cfg.Scoring.Significance = model.SignificanceConfig{Enabled: true, Permutations: 200, FDR: 0.5, Seed: 1}
+
+	// answers arrive interleaved across students in time order
+	streamed := append([]model.Answer(nil), answers...)
+	sort.SliceStable(streamed, func(i, j int) bool { return streamed[i].SubmittedAt < streamed[j].SubmittedAt })
+
+	ia, err := util.NewIncrementalAudit(exam, nil)
+	assert.Nil(t, err)
+	for _, ans := range streamed {
+		ia.Apply(ans)
+	}
+
+	want, wantMeta, err := util.GenerateAuditReport(context.Background(), exam, util.GenerateFlattenedTable(streamed))
+	assert.Nil(t, err)
+	snapshot := ia.Snapshot()
+	assert.Equal(t, want, snapshot.Report)
+	assert.Equal(t, wantMeta, snapshot.Metadata)
+	assert.Equal(t, util.GenerateFlattenedTable(streamed), snapshot.Answers)
+}
+
+func TestIncrementalAudit_SkipRatesMatchFullRecompute(t *testing.T) {
+	exam, answers := syntheticAnswers(12, 5)
+	withScoringConfig(t, 1)
+	config.Cfg.Scoring.Scorers = []model.ScorerConfig{
+		{Name: scoring.AnswerSimilarityName, Weight: 0.5, Enabled: true},
+		{Name: scoring.SkipPatternName, Weight: 0.5, Enabled: true},
+	}
+
+	// every student leaves a few questions blank , so skip rates move as the exam fills in
+	var kept []model.Answer
+	for _, ans := range answers {
+		if (ans.StudentID[len(ans.StudentID)-1]+ans.QuestionID[len(ans.QuestionID)-1])%3 != 0 {
+			kept = append(kept, ans)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].SubmittedAt < kept[j].SubmittedAt })
+
+	ia, err := util.NewIncrementalAudit(exam, nil)
+	assert.Nil(t, err)
+	for i, ans := range kept {
+		ia.Apply(ans)
+		if i%25 != 0 && i != len(kept)-1 {
+			continue
+		}
+		want, wantMeta, err := util.GenerateAuditReport(context.Background(), exam, util.GenerateFlattenedTable(kept[:i+1]))
+		assert.Nil(t, err)
+		snapshot := ia.Snapshot()
+		assert.Equal(t, want, snapshot.Report)
+		assert.Equal(t, wantMeta, snapshot.Metadata)
+	}
+}
+
+func TestDiffReports(t *testing.T) {
+	prev := model.AdjacencyList{
+		{StudentA: "s1", StudentB: "s2", Score: 0.8},
+		{StudentA: "s1", StudentB: "s3", Score: 0.75},
+		{StudentA: "s2", StudentB: "s4", Score: 0.9},
+	}
+	next := model.AdjacencyList{
+		{StudentA: "s2", StudentB: "s4", Score: 0.9},
+		{StudentA: "s1", StudentB: "s2", Score: 0.85, FlaggedQuestions: []string{"q1"}, Reason: &model.Reason{Summary: "s1-s2"}},
+		{StudentA: "s5", StudentB: "s6", Score: 0.72},
+	}
+
+	assert.Equal(t, []model.SuspicionEvent{
+		{Type: model.EventScoreChanged, StudentA: "s1", StudentB: "s2", Score: 0.85, PreviousScore: 0.8, FlaggedQuestions: []string{"q1"}, Summary: "s1-s2"},
+		{Type: model.EventPairFlagged, StudentA: "s5", StudentB: "s6", Score: 0.72},
+		{Type: model.EventPairCleared, StudentA: "s1", StudentB: "s3", PreviousScore: 0.75},
+	}, util.DiffReports(prev, next))
+	assert.Empty(t, util.DiffReports(next, next))
+}
+
+func TestIncrementalAudit_Subscribe(t *testing.T) {
+	exam, answers := syntheticAnswers(4, 2)
+	withScoringConfig(t, 1)
+
+	ia, err := util.NewIncrementalAudit(exam, nil)
+	assert.Nil(t, err)
+	updates, cancel := ia.Subscribe()
+
+	// signals coalesce while nobody is reading
+	ia.Apply(answers[0])
+	ia.Apply(answers[1])
+	<-updates
+	select {
+	case <-updates:
+		t.Fatal("expected a single pending signal")
+	default:
+	}
+
+	cancel()
+	ia.Apply(answers[2])
+	select {
+	case <-updates:
+		t.Fatal("cancelled subscription was signalled")
+	default:
+	}
+}
+
+func TestIncrementalAudit_Close(t *testing.T) {
+	exam, answers := syntheticAnswers(4, 2)
+	withScoringConfig(t, 1)
+
+	ia, err := util.NewIncrementalAudit(exam, nil)
+	assert.Nil(t, err)
+	updates, cancel := ia.Subscribe()
+	defer cancel()
+
+	// a pending signal is delivered before the channel reads as closed
+	ia.Apply(answers[0])
+	ia.Close()
+	_, ok := <-updates
+	assert.True(t, ok)
+	_, ok = <-updates
+	assert.False(t, ok)
+
+	late, lateCancel := ia.Subscribe()
+	defer lateCancel()
+	_, ok = <-late
+	assert.False(t, ok)
+}
+
+func TestIncrementalAudit_SnapshotSharedUntilNextRevision(t *testing.T) {
+	exam, answers := syntheticAnswers(6, 3)
+	withScoringConfig(t, 1)
+
+	ia, err := util.NewIncrementalAudit(exam, util.GenerateFlattenedTable(answers[:10]))
+	assert.Nil(t, err)
+
+	// subscribers reading the same revision share one report
+	first, second := ia.Snapshot(), ia.Snapshot()
+	assert.Equal(t, reflect.ValueOf(first.Answers).Pointer(), reflect.ValueOf(second.Answers).Pointer())
+
+	ia.Apply(answers[10])
+	next := ia.Snapshot()
+	assert.NotEqual(t, reflect.ValueOf(first.Answers).Pointer(), reflect.ValueOf(next.Answers).Pointer())
+	assert.Equal(t, util.GenerateFlattenedTable(answers[:11]), next.Answers)
+}
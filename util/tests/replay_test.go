@@ -0,0 +1,83 @@
+package util_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+	"github.com/deeraj-kumar/exam-audit/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReplaySpeed(t *testing.T) {
+	for in, want := range map[string]float64{"": 0, "instant": 0, "1x": 1, "10x": 10, "2.5": 2.5} {
+		speed, err := util.ParseReplaySpeed(in)
+		assert.Nil(t, err)
+		assert.Equal(t, want, speed)
+	}
+	for _, in := range []string{"fast", "0x", "-2x"} {
+		_, err := util.ParseReplaySpeed(in)
+		assert.ErrorIs(t, err, util.ErrInvalidSpeed)
+	}
+}
+
+func TestReplay_TimelineEndsOnFinalReport(t *testing.T) {
+	exam, answers := syntheticAnswers(20, 5)
+	withScoringConfig(t, 1)
+
+	var timeline []model.TimelineEvent
+	err := util.Replay(context.Background(), exam, answers, 0, func(event model.TimelineEvent) {
+		timeline = append(timeline, event)
+	})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, timeline)
+
+	// playing the events back gives the pairs of the report over the whole exam
+	flagged := make(map[[2]string]float64)
+	var last int64
+	for _, event := range timeline {
+		assert.GreaterOrEqual(t, event.At, last)
+		last = event.At
+		key := [2]string{event.StudentA, event.StudentB}
+		if event.Type == model.EventPairCleared {
+			delete(flagged, key)
+			continue
+		}
+		flagged[key] = event.Score
+	}
+
+	report, _, err := util.GenerateAuditReport(context.Background(), exam, util.GenerateFlattenedTable(answers))
+	assert.Nil(t, err)
+	want := make(map[[2]string]float64)
+	for _, item := range report {
+		want[[2]string{item.StudentA, item.StudentB}] = item.Score
+	}
+	assert.Equal(t, want, flagged)
+}
+
+func TestReplay_PacedAndCancelled(t *testing.T) {
+	withScoringConfig(t, 1)
+	answers := []model.Answer{
+		{QuestionID: "q1", Ans: "A", StudentID: "s1", SubmittedAt: 100},
+		{QuestionID: "q1", Ans: "A", StudentID: "s2", SubmittedAt: 101},
+		{QuestionID: "q1", Ans: "B", StudentID: "s3", SubmittedAt: 400},
+	}
+
+	// one second of exam time at 20x takes 50ms
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	var events []model.TimelineEvent
+	err := util.Replay(ctx, model.Exam{}, answers, 20, func(event model.TimelineEvent) {
+		events = append(events, event)
+	})
+
+	// the 300 second gap to s3 outlasts the deadline
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	assert.Len(t, events, 1)
+	assert.Equal(t, model.EventPairFlagged, events[0].Type)
+	assert.Equal(t, int64(101), events[0].At)
+	assert.Equal(t, int64(1), events[0].OffsetSeconds)
+}
@@ -0,0 +1,198 @@
+package util_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/deeraj-kumar/exam-audit/config"
+	model "github.com/deeraj-kumar/exam-audit/domain"
+	"github.com/deeraj-kumar/exam-audit/scoring"
+	"github.com/deeraj-kumar/exam-audit/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// syntheticCohort builds a reproducible exam where every student answers every question once or twice
+func syntheticCohort(students, questions int) (model.Exam, map[string]map[string][]model.AnswerRevision) {
+	exam, answers := syntheticAnswers(students, questions)
+	return exam, util.GenerateFlattenedTable(answers)
+}
+
+func syntheticAnswers(students, questions int) (model.Exam, []model.Answer) {
+	rng := rand.New(rand.NewSource(42))
+	options := []string{"Option A", "Option B", "Option C", "Option D"}
+
+	exam := model.Exam{ExamID: "bench"}
+	for q := 0; q < questions; q++ {
+		exam.Questions = append(exam.Questions, model.Question{
+			QuestionID:    fmt.Sprintf("q%03d", q),
+			Type:          model.QuestionTypeMCQ,
+			Options:       options,
+			CorrectAnswer: options[q%len(options)],
+		})
+	}
+
+	var answers []model.Answer
+	for s := 0; s < students; s++ {
+		sid := fmt.Sprintf("s%04d", s)
+		at := int64(1700000000 + rng.Intn(60))
+		for _, q := range exam.Questions {
+			for r := 0; r <= rng.Intn(2); r++ {
+				at += int64(10 + rng.Intn(90))
+				answers = append(answers, model.Answer{QuestionID: q.QuestionID, StudentID: sid, Ans: options[rng.Intn(len(options))], SubmittedAt: at})
+			}
+		}
+	}
+	return exam, answers
+}
+
+func withScoringConfig(t testing.TB, workers int) {
+	original := config.Cfg
+	t.Cleanup(func() {
+		config.Cfg = original
+	})
+	config.Cfg = model.Config{SuspicionScoreThreshold: 0.7}
+	config.Cfg.Scoring.Workers = workers
+}
+
+func TestGenerateAuditReport_SameResultForAnyWorkerCount(t *testing.T) {
+	exam, table := syntheticCohort(40, 8)
+
+	withScoringConfig(t, 1)
+	sequential, _, err := util.GenerateAuditReport(context.Background(), exam, table)
+	assert.Nil(t, err)
+
+	withScoringConfig(t, 8)
+	parallel, _, err := util.GenerateAuditReport(context.Background(), exam, table)
+	assert.Nil(t, err)
+
+	assert.NotEmpty(t, sequential)
+	assert.Equal(t, sequential, parallel)
+}
+
+func TestGenerateAuditReport_PrunedPairsInMetadata(t *testing.T) {
+	exam, table := syntheticCohort(40, 8)
+	withScoringConfig(t, 2)
+
+	_, full, err := util.GenerateAuditReport(context.Background(), exam, table)
+	assert.Nil(t, err)
+	assert.Equal(t, model.ReportMetadata{Students: 40, TotalPairs: 780, ScoredPairs: 780}, full)
+
+	config.Cfg.Scoring.Pruning = model.PruningConfig{Enabled: true, MinStudents: 2, Recall: 0.9, SimilarityThreshold: 0.9}
+	_, pruned, err := util.GenerateAuditReport(context.Background(), exam, table)
+	assert.Nil(t, err)
+	assert.Equal(t, 780, pruned.TotalPairs)
+	assert.Greater(t, pruned.PrunedPairs, 0)
+	assert.Equal(t, pruned.TotalPairs, pruned.ScoredPairs+pruned.PrunedPairs)
+}
+
+func TestGenerateAuditReport_Cancelled(t *testing.T) {
+	exam, table := syntheticCohort(40, 8)
+	withScoringConfig(t, 4)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err := util.GenerateAuditReport(ctx, exam, table)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// BenchmarkGenerateAuditReport scales with GOMAXPROCS , compare with go test -bench . -cpu 1,2,4,8
+func BenchmarkGenerateAuditReport(b *testing.B) {
+	exam, table := syntheticCohort(300, 20)
+	withScoringConfig(b, 0)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := util.GenerateAuditReport(context.Background(), exam, table); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestGenerateAuditReport_PlantedPairIsSignificant(t *testing.T) {
+	if testing.Short() {
+		t.Skip("scores a null distribution of 99000 pairs")
+	}
+	exam, answers := syntheticAnswers(100, 20)
+	// s0001 copies every revision of s0000 a few seconds later
+	var planted []model.Answer
+	for _, a := range answers {
+		if a.StudentID == "s0001" {
+			continue
+		}
+		planted = append(planted, a)
+		if a.StudentID == "s0000" {
+			planted = append(planted, model.Answer{QuestionID: a.QuestionID, StudentID: "s0001", Ans: a.Ans, SubmittedAt: a.SubmittedAt + 5})
+		}
+	}
+	withScoringConfig(t, 0)
+	config.Cfg.Scoring.Aggregation.Combiner = scoring.CombinerMean
+	config.Cfg.Scoring.Significance = model.SignificanceConfig{Enabled: true, Permutations: 1000, FDR: 0.05, Seed: 1}
+
+	record, meta, err := util.GenerateAuditReport(context.Background(), exam, util.GenerateFlattenedTable(planted))
+	assert.Nil(t, err)
+	assert.Equal(t, 4950, meta.TotalPairs)
+	assert.NotEmpty(t, record)
+	assert.Equal(t, "s0000", record[0].StudentA)
+	assert.Equal(t, "s0001", record[0].StudentB)
+	// 1000 permutations could never beat 4950 tests at 5% , the null grows to 99000
+	assert.Less(t, record[0].PValue, 1.0/1001)
+	assert.LessOrEqual(t, record[0].QValue, 0.05)
+}
+
+func TestGenerateAuditReport_RejectsUnreachableSignificance(t *testing.T) {
+	exam, table := syntheticCohort(100, 4)
+	withScoringConfig(t, 1)
+	config.Cfg.Scoring.Significance = model.SignificanceConfig{Enabled: true, Permutations: 1000, MaxPermutations: 10000, FDR: 0.05}
+
+	// the max combiner scores most random pairs one , nothing could ever come out significant
+	_, _, err := util.GenerateAuditReport(context.Background(), exam, table)
+	assert.ErrorContains(t, err, "combiner")
+
+	// 4950 tests at 5% need 99000 permutations
+	config.Cfg.Scoring.Aggregation.Combiner = scoring.CombinerMean
+	_, _, err = util.GenerateAuditReport(context.Background(), exam, table)
+	assert.ErrorContains(t, err, "max_permutations")
+}
+
+func TestGenerateAuditReport_ComparesQuestionsOfEitherStudent(t *testing.T) {
+	withScoringConfig(t, 1)
+	exam := model.Exam{ExamID: "e1", Questions: []model.Question{
+		{QuestionID: "q1", Type: model.QuestionTypeMCQ, Options: []string{"A", "B"}},
+		{QuestionID: "q2", Type: model.QuestionTypeMCQ, Options: []string{"A", "B"}},
+	}}
+	// s1 skipped q1 , s2 did not , the pair is still compared on q1
+	table := map[string]map[string][]model.AnswerRevision{
+		"s1": {"q2": {{SubmittedAt: 100, Ans: "A"}}},
+		"s2": {"q1": {{SubmittedAt: 90, Ans: "B"}}, "q2": {{SubmittedAt: 105, Ans: "A"}}},
+	}
+
+	record, _, err := util.GenerateAuditReport(context.Background(), exam, table)
+	assert.Nil(t, err)
+	assert.Len(t, record, 1)
+	var compared []string
+	for _, q := range record[0].Questions {
+		compared = append(compared, q.QuestionID)
+	}
+	assert.Equal(t, []string{"q1", "q2"}, compared)
+}
+
+func TestGenerateAuditReport_ExamScoreKeptApart(t *testing.T) {
+	withScoringConfig(t, 1)
+	config.Cfg.Scoring.Scorers = []model.ScorerConfig{
+		{Name: scoring.AnswerSimilarityName, Weight: 1, Enabled: true},
+		{Name: scoring.ResponseVectorName, Weight: 1, Enabled: true},
+	}
+	exam, table := syntheticCohort(2, 6)
+	// one identical answer out of six gives a flagged question and a weak exam-level match
+	table["s0001"]["q000"] = table["s0000"]["q000"]
+
+	record, _, err := util.GenerateAuditReport(context.Background(), exam, table)
+	assert.Nil(t, err)
+	assert.Len(t, record, 1)
+	assert.Contains(t, record[0].FlaggedQuestions, "q000")
+	assert.Less(t, record[0].ExamScore, 0.7)
+	// the pair keeps its question score instead of being averaged down with the exam-level one
+	assert.Greater(t, record[0].Score, 0.7)
+}
@@ -0,0 +1,22 @@
+package util_test
+
+import (
+	"testing"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+	"github.com/deeraj-kumar/exam-audit/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconcileRoster(t *testing.T) {
+	roster := []model.Student{{StudentID: "s1"}, {StudentID: "s3"}, {StudentID: "s2"}}
+
+	check := util.ReconcileRoster(roster, []string{"s9", "s1", "s4"})
+	assert.Equal(t, []string{"s4", "s9"}, check.NotOnRoster)
+	assert.Equal(t, []string{"s2", "s3"}, check.NoSubmission)
+
+	check = util.ReconcileRoster(roster, []string{"s1", "s2", "s3"})
+	assert.NotNil(t, check.NotOnRoster)
+	assert.Empty(t, check.NotOnRoster)
+	assert.Empty(t, check.NoSubmission)
+}
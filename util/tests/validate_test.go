@@ -0,0 +1,58 @@
+package util_test
+
+import (
+	"errors"
+	"testing"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+	"github.com/deeraj-kumar/exam-audit/util"
+	"github.com/stretchr/testify/assert"
+)
+
+var options = []string{"Option A", "Option B", "Option C", "Option D"}
+
+func TestValidateAnswer(t *testing.T) {
+	tests := []struct {
+		name  string
+		q     model.Question
+		ans   string
+		valid bool
+	}{
+		{name: "untyped accepts anything", q: model.Question{}, ans: "anything", valid: true},
+		{name: "mcq option", q: model.Question{Type: model.QuestionTypeMCQ, Options: options}, ans: "option b", valid: true},
+		{name: "mcq unknown option", q: model.Question{Type: model.QuestionTypeMCQ, Options: options}, ans: "Option E", valid: false},
+		{name: "multi select", q: model.Question{Type: model.QuestionTypeMultiSelect, Options: options}, ans: "Option A, Option C", valid: true},
+		{name: "multi select duplicate", q: model.Question{Type: model.QuestionTypeMultiSelect, Options: options}, ans: "Option A,Option A", valid: false},
+		{name: "multi select too many", q: model.Question{Type: model.QuestionTypeMultiSelect, Options: options, Metadata: model.QuestionMetadata{MaxSelections: 1}}, ans: "Option A,Option B", valid: false},
+		{name: "numeric", q: model.Question{Type: model.QuestionTypeNumeric}, ans: " 3.14 ", valid: true},
+		{name: "numeric not a number", q: model.Question{Type: model.QuestionTypeNumeric}, ans: "pi", valid: false},
+		{name: "numeric NaN", q: model.Question{Type: model.QuestionTypeNumeric}, ans: "NaN", valid: false},
+		{name: "numeric infinity", q: model.Question{Type: model.QuestionTypeNumeric}, ans: "+Infinity", valid: false},
+		{name: "numeric inf", q: model.Question{Type: model.QuestionTypeNumeric}, ans: "-inf", valid: false},
+		{name: "free text too long", q: model.Question{Type: model.QuestionTypeFreeText, Metadata: model.QuestionMetadata{MaxLength: 5}}, ans: "goroutines", valid: false},
+		{name: "code", q: model.Question{Type: model.QuestionTypeCode, Metadata: model.QuestionMetadata{Language: "go"}}, ans: "go f()", valid: true},
+		{name: "unsupported type", q: model.Question{Type: "essay"}, ans: "x", valid: false},
+	}
+	for _, tc := range tests {
+		err := util.ValidateAnswer(tc.q, tc.ans)
+		if tc.valid {
+			assert.Nil(t, err, tc.name)
+			continue
+		}
+		assert.True(t, errors.Is(err, util.ErrInvalidAnswer), tc.name)
+	}
+}
+
+func TestFindQuestion(t *testing.T) {
+	exams := model.Exams{Exams: []model.Exam{{ExamID: "e1", Questions: []model.Question{{QuestionID: "q1"}}}}}
+
+	q, err := util.FindQuestion(exams, "e1", "q1")
+	assert.Nil(t, err)
+	assert.Equal(t, "q1", q.QuestionID)
+
+	_, err = util.FindQuestion(exams, "e1", "q2")
+	assert.True(t, errors.Is(err, util.ErrUnknownQuestion))
+
+	_, err = util.FindQuestion(exams, "e2", "q1")
+	assert.True(t, errors.Is(err, util.ErrUnknownQuestion))
+}
@@ -1,15 +1,20 @@
 package util
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
-	"math"
 	"os"
+	"runtime"
+	"sort"
+	"sync"
 
+	"github.com/deeraj-kumar/exam-audit/candidate"
 	"github.com/deeraj-kumar/exam-audit/config"
 	model "github.com/deeraj-kumar/exam-audit/domain"
+	"github.com/deeraj-kumar/exam-audit/scoring"
 	"github.com/hyperledger/fabric-gateway/pkg/identity"
 )
 
@@ -62,124 +67,377 @@ func GenerateFlattenedTable(editedAnswerList []model.Answer) map[string]map[stri
 	return out
 }
 
-// GenerateAuditReport compares every pair of students and produces adjacency list
-func GenerateAuditReport(studentAnswersMap map[string]map[string][]model.AnswerRevision) (record model.AdjacencyList) {
-	susScoreThreshold := config.Cfg.SuspicionScoreThreshold
+// GenerateAuditReport compares every pair of students and produces adjacency list with one item per flagged pair .
+// When significance testing is enabled , pairs are selected by their false discovery rate instead of the fixed threshold .
+// Pairs are scored by a bounded pool of workers and the audit stops early when ctx is cancelled . With pruning
+// enabled only the candidate pairs found by locality-sensitive hashing are scored , the metadata says how many
+// pairs were skipped
+func GenerateAuditReport(ctx context.Context, exam model.Exam, studentAnswersMap map[string]map[string][]model.AnswerRevision) (model.AdjacencyList, model.ReportMetadata, error) {
+	rb, err := newReportBuilder(exam, studentAnswersMap)
+	if err != nil {
+		return nil, model.ReportMetadata{}, err
+	}
+
+	studentIDs := sortedStudentIDs(studentAnswersMap)
+	candidates := candidate.Select(exam, studentIDs, studentAnswersMap, config.Cfg.Scoring.Pruning)
+	pairs, err := rb.scoreAllPairs(ctx, studentIDs, studentAnswersMap, candidates, config.Cfg.Scoring.Workers)
+	if err != nil {
+		return nil, model.ReportMetadata{}, err
+	}
+	record, meta, err := rb.finish(ctx, studentAnswersMap, pairs)
+	if err != nil {
+		return nil, model.ReportMetadata{}, err
+	}
+	log.Printf("Audit scored %d pairs across %d students , %d pairs pruned", meta.ScoredPairs, meta.Students, meta.PrunedPairs)
+	return record, meta, nil
+}
+
+func newReportBuilder(exam model.Exam, studentAnswersMap map[string]map[string][]model.AnswerRevision) (*reportBuilder, error) {
+	scoringCfg := config.Cfg.Scoring
 
+	pipeline, err := scoring.NewPipeline(scoringCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build scoring pipeline: %w", err)
+	}
+	combine, err := scoring.NewCombiner(scoringCfg.Aggregation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build score combiner: %w", err)
+	}
+
+	rb := &reportBuilder{
+		threshold:    config.Cfg.SuspicionScoreThreshold,
+		pipeline:     pipeline,
+		combine:      combine,
+		questions:    make(map[string]model.Question, len(exam.Questions)),
+		examOrder:    exam.Questions,
+		cohort:       scoring.NewCohort(studentAnswersMap),
+		lag:          scoringCfg.Lag,
+		significance: scoringCfg.Significance,
+		workers:      scoringCfg.Workers,
+	}
+	for _, q := range exam.Questions {
+		rb.questions[q.QuestionID] = q
+	}
+	if rb.significance.Enabled {
+		if err := scoring.ValidateSignificanceCombiner(scoringCfg.Aggregation); err != nil {
+			return nil, err
+		}
+		students := len(studentAnswersMap)
+		if _, err := scoring.Permutations(rb.significance, students*(students-1)/2); err != nil {
+			return nil, err
+		}
+	}
+	return rb, nil
+}
+
+// sortedStudentIDs gives every pair a canonical StudentA < StudentB orientation
+func sortedStudentIDs(studentAnswersMap map[string]map[string][]model.AnswerRevision) []string {
 	studentIDs := make([]string, 0, len(studentAnswersMap))
 	for sid := range studentAnswersMap {
 		studentIDs = append(studentIDs, sid)
 	}
+	sort.Strings(studentIDs)
+	return studentIDs
+}
 
-	for i := 0; i < len(studentIDs); i++ {
-		aID := studentIDs[i]
-		std1AnsMap := studentAnswersMap[aID]
-		for qID := range std1AnsMap {
-			std1AnswerRevisions := std1AnsMap[qID]
-			for j := i + 1; j < len(studentIDs); j++ {
-				bID := studentIDs[j]
-				std2AnswerRevisions := studentAnswersMap[bID][qID]
+// finish turns the scored pairs into the sorted report , keeping flagged pairs or , with significance testing
+// enabled , the pairs that survive the false discovery rate
+func (rb *reportBuilder) finish(ctx context.Context, studentAnswersMap map[string]map[string][]model.AnswerRevision, pairs model.AdjacencyList) (model.AdjacencyList, model.ReportMetadata, error) {
+	students := len(studentAnswersMap)
+	total := students * (students - 1) / 2
+	meta := model.ReportMetadata{Students: students, TotalPairs: total, ScoredPairs: len(pairs), PrunedPairs: total - len(pairs)}
+
+	if rb.significance.Enabled {
+		record, err := rb.selectSignificant(ctx, rb.significance, studentAnswersMap, pairs, meta.PrunedPairs)
+		if err != nil {
+			return nil, meta, err
+		}
+		SortReport(record)
+		return record, meta, nil
+	}
 
-				score := questionScore(std1AnswerRevisions, std2AnswerRevisions)
-				log.Printf("Audit score: %f between Students : %s - %s", score, aID, bID)
-				if score <= 0 || score <= susScoreThreshold {
+	var record model.AdjacencyList
+	for _, pair := range pairs {
+		if !isFlagged(pair) {
+			continue
+		}
+		pair.Reason.Summary = scoring.Summarize(pair)
+		record = append(record, pair)
+	}
+	SortReport(record)
+	return record, meta, nil
+}
+
+// SortReport orders a report by score , highest first , then by the student ids of each pair
+func SortReport(record model.AdjacencyList) {
+	sort.SliceStable(record, func(i, j int) bool {
+		if record[i].Score != record[j].Score {
+			return record[i].Score > record[j].Score
+		}
+		if record[i].StudentA != record[j].StudentA {
+			return record[i].StudentA < record[j].StudentA
+		}
+		return record[i].StudentB < record[j].StudentB
+	})
+}
+
+// reportBuilder holds the state shared by every pair comparison of a single audit
+type reportBuilder struct {
+	threshold    float64
+	pipeline     *scoring.Pipeline
+	combine      scoring.Combiner
+	questions    map[string]model.Question
+	examOrder    []model.Question
+	cohort       *scoring.Cohort
+	lag          model.LagConfig
+	significance model.SignificanceConfig
+	workers      int
+}
+
+// scoreAllPairs shards the pair space by row , one row being every pair (i , j>i) of student i , across a
+// bounded pool of workers . Rows are merged back in order so the result does not depend on scheduling .
+// When candidates is not nil a row only holds the candidate partners of student i
+func (rb *reportBuilder) scoreAllPairs(ctx context.Context, studentIDs []string, studentAnswersMap map[string]map[string][]model.AnswerRevision, candidates candidate.Candidates, workers int) (model.AdjacencyList, error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = max(1, min(workers, len(studentIDs)))
+
+	rows := make([]model.AdjacencyList, len(studentIDs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if ctx.Err() != nil {
 					continue
 				}
-				adj := model.AdjacencyItem{
-					StudentA: aID,
-					StudentB: bID,
-					Score:    score,
+				aID := studentIDs[i]
+				partners := allPartners(i, len(studentIDs))
+				if candidates != nil {
+					partners = candidates[i]
 				}
-				record = append(record, adj)
+				row := make(model.AdjacencyList, 0, len(partners))
+				for _, j := range partners {
+					bID := studentIDs[j]
+					row = append(row, rb.scorePair(aID, bID, studentAnswersMap[aID], studentAnswersMap[bID]))
+				}
+				rows[i] = row
 			}
-		}
+		}()
 	}
-	return
-}
 
-func questionScore(stdA, stdB []model.AnswerRevision) float64 {
-	finalAnsA := stdA[len(stdA)-1].Ans
-	finalAnsB := stdB[len(stdB)-1].Ans
+dispatch:
+	for i := range studentIDs {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
 
-	var stdATimeStamps, stdBTimeStamps []int64
-	for _, t := range stdA {
-		stdATimeStamps = append(stdATimeStamps, t.SubmittedAt)
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("audit cancelled: %w", err)
 	}
 
-	for _, t := range stdB {
-		stdBTimeStamps = append(stdBTimeStamps, t.SubmittedAt)
+	var pairs model.AdjacencyList
+	for _, row := range rows {
+		pairs = append(pairs, row...)
 	}
+	return pairs, nil
+}
 
-	var stdAEdits, stdBEdits []string
-	for _, t := range stdA {
-		stdAEdits = append(stdAEdits, t.Ans)
+func allPartners(i, n int) []int {
+	out := make([]int, 0, n-i-1)
+	for j := i + 1; j < n; j++ {
+		out = append(out, j)
 	}
+	return out
+}
+
+// isFlagged reports whether a pair went over the threshold on any question or over the whole exam
+func isFlagged(pair model.AdjacencyItem) bool {
+	return len(pair.FlaggedQuestions) > 0 || pair.ExamFlagged
+}
 
-	for _, t := range stdB {
-		stdBEdits = append(stdBEdits, t.Ans)
+// scorePair scores two students on every question answered by either of them and aggregates the result
+func (rb *reportBuilder) scorePair(aID, bID string, a, b map[string][]model.AnswerRevision) model.AdjacencyItem {
+	results := make(map[string]questionResult, len(a))
+	for qID, aRevisions := range a {
+		results[qID] = rb.scoreQuestion(qID, aRevisions, b[qID])
+	}
+	for qID, bRevisions := range b {
+		if _, ok := a[qID]; !ok {
+			results[qID] = rb.scoreQuestion(qID, nil, bRevisions)
+		}
 	}
+	return rb.assemble(aID, bID, a, b, results, rb.scoreExam(a, b))
+}
 
-	as := answerSimilarity(finalAnsA, finalAnsB)
-	ts := timeCorrelation(stdATimeStamps, stdBTimeStamps)
-	es := editPatternScore(stdAEdits, stdBEdits)
+// questionResult is the comparison of a pair on a single question , evidence is only kept when flagged
+type questionResult struct {
+	score    model.QuestionScore
+	evidence *model.QuestionEvidence
+}
 
-	return (0.5 * as) + (0.3 * ts) + (0.2 * es)
+func (rb *reportBuilder) scoreQuestion(qID string, a, b []model.AnswerRevision) questionResult {
+	in := scoring.Input{QuestionID: qID, Question: rb.questions[qID], A: a, B: b, Cohort: rb.cohort}
+	res := rb.pipeline.Evaluate(in)
+	isFlagged := res.Score > 0 && res.Score > rb.threshold
+	out := questionResult{score: model.QuestionScore{QuestionID: qID, Score: res.Score, Flagged: isFlagged}}
+	if isFlagged {
+		evidence := scoring.Explain(in, res)
+		out.evidence = &evidence
+	}
+	return out
 }
 
-func answerSimilarity(a, b string) float64 {
-	if len(a) == 0 || len(b) == 0 {
-		return 0
+// scoreExam runs the exam-level scorers , nil when none is enabled
+func (rb *reportBuilder) scoreExam(a, b map[string][]model.AnswerRevision) *scoring.Result {
+	if !rb.pipeline.HasExamScorers() {
+		return nil
 	}
-	if a == b {
-		return 1.0
+	res := rb.pipeline.EvaluateExam(scoring.ExamInput{Questions: rb.examOrder, A: a, B: b, Cohort: rb.cohort})
+	return &res
+}
+
+// assemble aggregates the per-question and exam-level results of a pair and , when the pair is flagged ,
+// works out who leads and who copied
+func (rb *reportBuilder) assemble(aID, bID string, a, b map[string][]model.AnswerRevision, results map[string]questionResult, examRes *scoring.Result) model.AdjacencyItem {
+	qIDs := make([]string, 0, len(results))
+	for qID := range results {
+		qIDs = append(qIDs, qID)
+	}
+	sort.Strings(qIDs)
+
+	var questions []model.QuestionScore
+	var flagged []string
+	var evidence []model.QuestionEvidence
+	for _, qID := range qIDs {
+		res := results[qID]
+		questions = append(questions, res.score)
+		if res.score.Flagged {
+			flagged = append(flagged, qID)
+			evidence = append(evidence, *res.evidence)
+		}
 	}
 
-	maxLen := math.Max(float64(len(a)), float64(len(b)))
-	minLen := math.Min(float64(len(a)), float64(len(b)))
+	item := model.AdjacencyItem{
+		StudentA:         aID,
+		StudentB:         bID,
+		Score:            rb.combine(questions),
+		FlaggedQuestions: flagged,
+		Questions:        questions,
+		Reason:           &model.Reason{Evidence: evidence},
+	}
+	if examRes != nil {
+		// the exam-level result stands on its own , blending it in could pull a pair flagged on a question below the threshold
+		item.ExamScore = examRes.Score
+		item.ExamScores = examRes.SubScores
+		item.ExamFlagged = examRes.Score > 0 && examRes.Score > rb.threshold
+	}
 
-	return minLen / maxLen
+	if isFlagged(item) {
+		attributed := flagged
+		if len(attributed) == 0 {
+			attributed = qIDs
+		}
+		lag := scoring.TimelineLag(a, b, rb.lag)
+		item.Lag = leadLag(aID, bID, lag)
+		item.Direction = direction(aID, bID, scoring.Attribute(a, b, attributed, lag))
+	}
+	return item
 }
 
-func timeCorrelation(aTime, bTime []int64) float64 {
-	minLen := int(math.Min(float64(len(aTime)), float64(len(bTime))))
-	if len(aTime) > minLen {
-		aTime = aTime[minLen:]
+// direction maps an attribution onto the student ids , nil when the source cannot be told apart
+func direction(aID, bID string, att scoring.Attribution) *model.Direction {
+	switch att.Source {
+	case scoring.LeaderA:
+		return &model.Direction{Source: aID, Target: bID, Confidence: att.Confidence, FirstCommit: att.FirstCommit[0], Convergence: att.Convergence[0]}
+	case scoring.LeaderB:
+		return &model.Direction{Source: bID, Target: aID, Confidence: att.Confidence, FirstCommit: att.FirstCommit[1], Convergence: att.Convergence[1]}
+	default:
+		return nil
 	}
+}
 
-	if len(bTime) > minLen {
-		bTime = bTime[minLen:]
+// leadLag names the leading and following student of a pair , nil when neither clearly edits first
+func leadLag(aID, bID string, lag scoring.Lag) *model.LeadLag {
+	switch lag.Leader() {
+	case scoring.LeaderA:
+		return &model.LeadLag{Leader: aID, Follower: bID, LagSeconds: lag.LagSeconds, Strength: lag.Strength}
+	case scoring.LeaderB:
+		return &model.LeadLag{Leader: bID, Follower: aID, LagSeconds: -lag.LagSeconds, Strength: lag.Strength}
+	default:
+		return nil
 	}
+}
 
-	var sum float64
-	for i := minLen - 1; i >= 0; i-- {
-		diff := math.Abs(float64(aTime[i] - bTime[i]))
-		score := math.Max(0, 1-(diff/60.0))
-		sum += score
+// selectSignificant attaches an empirical p-value to every pair and keeps the pairs that survive
+// Benjamini-Hochberg at the configured false discovery rate . Pruned pairs still count as tests , with a
+// p-value of one , so pruning never makes the correction less strict , and the null distribution grows with
+// the number of tests so the smallest p-value can still pass
+func (rb *reportBuilder) selectSignificant(ctx context.Context, cfg model.SignificanceConfig, studentAnswersMap map[string]map[string][]model.AnswerRevision, pairs model.AdjacencyList, pruned int) (model.AdjacencyList, error) {
+	permutations, err := scoring.Permutations(cfg, len(pairs)+pruned)
+	if err != nil {
+		// a live audit outgrowing the cap keeps testing , at the weaker resolution the cap allows
+		log.Printf("significance testing capped , err - %v", err)
 	}
-	return sum / float64(minLen)
-}
+	cfg.Permutations = permutations
 
-func editPatternScore(aEdits, bEdits []string) float64 {
-	minLen := int(math.Min(float64(len(aEdits)), float64(len(bEdits))))
-	if minLen == 0 {
-		return 0
+	adjacent := make(map[string]bool)
+	for _, pair := range pairs {
+		if isFlagged(pair) {
+			adjacent[pair.StudentA] = true
+			adjacent[pair.StudentB] = true
+		}
+	}
+	var pool []string
+	for sid := range studentAnswersMap {
+		if !adjacent[sid] {
+			pool = append(pool, sid)
+		}
+	}
+	if len(pool) < 2 {
+		pool = pool[:0]
+		for sid := range studentAnswersMap {
+			pool = append(pool, sid)
+		}
 	}
+	sort.Strings(pool)
 
-	if len(aEdits) > minLen {
-		aEdits = aEdits[minLen:]
+	null, err := scoring.NullDistribution(ctx, cfg, studentAnswersMap, pool, rb.workers, func(a, b map[string][]model.AnswerRevision) float64 {
+		return rb.scorePair("", "", a, b).Score
+	})
+	if err != nil || len(null) == 0 {
+		return nil, err
 	}
 
-	if len(bEdits) > minLen {
-		bEdits = bEdits[minLen:]
+	pValues := make([]float64, len(pairs), len(pairs)+pruned)
+	for i, pair := range pairs {
+		pValues[i] = scoring.PValue(null, pair.Score)
+	}
+	for k := 0; k < pruned; k++ {
+		pValues = append(pValues, 1)
 	}
+	qValues := scoring.BenjaminiHochberg(pValues)
 
-	var match float64
-	for i := minLen - 1; i >= 0; i-- {
-		if aEdits[i] == bEdits[i] {
-			match++
+	fdr := scoring.FDR(cfg)
+	var record model.AdjacencyList
+	for i, pair := range pairs {
+		if qValues[i] > fdr {
+			continue
 		}
+		pair.PValue = pValues[i]
+		pair.QValue = qValues[i]
+		pair.Reason.Summary = scoring.Summarize(pair)
+		record = append(record, pair)
 	}
-	return match / float64(minLen)
+	return record, nil
 }
 
 func LoadX509Identity(certPath, mspID string) (*identity.X509Identity, error) {
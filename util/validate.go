@@ -0,0 +1,95 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	model "github.com/deeraj-kumar/exam-audit/domain"
+	"github.com/deeraj-kumar/exam-audit/scoring"
+)
+
+var (
+	ErrUnknownExam     = errors.New("unknown exam")
+	ErrUnknownQuestion = errors.New("unknown exam or question")
+	ErrInvalidAnswer   = errors.New("invalid answer")
+)
+
+// FindExam returns the exam examID
+func FindExam(exams model.Exams, examID string) (model.Exam, error) {
+	for _, e := range exams.Exams {
+		if e.ExamID == examID {
+			return e, nil
+		}
+	}
+	return model.Exam{}, fmt.Errorf("%w: exam %s not found", ErrUnknownExam, examID)
+}
+
+// FindQuestion returns the question questionID of exam examID
+func FindQuestion(exams model.Exams, examID, questionID string) (model.Question, error) {
+	for _, e := range exams.Exams {
+		if e.ExamID != examID {
+			continue
+		}
+		for _, q := range e.Questions {
+			if q.QuestionID == questionID {
+				return q, nil
+			}
+		}
+		return model.Question{}, fmt.Errorf("%w: question %s not found in exam %s", ErrUnknownQuestion, questionID, examID)
+	}
+	return model.Question{}, fmt.Errorf("%w: exam %s not found", ErrUnknownQuestion, examID)
+}
+
+// ValidateAnswer checks ans against the type of q . Untyped questions accept any answer
+func ValidateAnswer(q model.Question, ans string) error {
+	switch q.Type {
+	case "":
+		return nil
+	case model.QuestionTypeMCQ:
+		if !hasOption(q, scoring.Normalize(ans)) {
+			return fmt.Errorf("%w: %q is not an option of question %s", ErrInvalidAnswer, ans, q.QuestionID)
+		}
+	case model.QuestionTypeMultiSelect:
+		selections := scoring.SplitSelections(ans)
+		if len(selections) == 0 {
+			return fmt.Errorf("%w: no option selected for question %s", ErrInvalidAnswer, q.QuestionID)
+		}
+		if limit := q.Metadata.MaxSelections; limit > 0 && len(selections) > limit {
+			return fmt.Errorf("%w: %d options selected for question %s , at most %d allowed", ErrInvalidAnswer, len(selections), q.QuestionID, limit)
+		}
+		seen := make(map[string]bool, len(selections))
+		for _, s := range selections {
+			if !hasOption(q, s) {
+				return fmt.Errorf("%w: %q is not an option of question %s", ErrInvalidAnswer, s, q.QuestionID)
+			}
+			if seen[s] {
+				return fmt.Errorf("%w: option %q selected twice for question %s", ErrInvalidAnswer, s, q.QuestionID)
+			}
+			seen[s] = true
+		}
+	case model.QuestionTypeNumeric:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(ans), 64); err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Errorf("%w: %q is not a number for question %s", ErrInvalidAnswer, ans, q.QuestionID)
+		}
+	case model.QuestionTypeFreeText, model.QuestionTypeCode:
+		if limit := q.Metadata.MaxLength; limit > 0 && utf8.RuneCountInString(ans) > limit {
+			return fmt.Errorf("%w: answer for question %s exceeds %d characters", ErrInvalidAnswer, q.QuestionID, limit)
+		}
+	default:
+		return fmt.Errorf("%w: question %s has unsupported type %s", ErrInvalidAnswer, q.QuestionID, q.Type)
+	}
+	return nil
+}
+
+func hasOption(q model.Question, normalized string) bool {
+	for _, o := range q.Options {
+		if scoring.Normalize(o) == normalized {
+			return true
+		}
+	}
+	return false
+}